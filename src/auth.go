@@ -0,0 +1,94 @@
+package tsweb
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Accounts maps user names to passwords for BasicAuth.
+type Accounts map[string]string
+
+// BearerValidator validates a bearer token and returns the authenticated principal.
+type BearerValidator func(c *Context, token string) (string, bool)
+
+// Principal returns the principal recorded by an authentication middleware, or ""
+// if the request has not been authenticated.
+func (p *Context) Principal() string {
+	return p.principal
+}
+
+// SetPrincipal records the authenticated principal for the request.
+func (p *Context) SetPrincipal(principal string) {
+	p.principal = principal
+}
+
+// BasicAuth is a middleware requiring HTTP Basic authentication against accounts.
+// Credentials are compared in constant time and the user name becomes the principal.
+func BasicAuth(accounts Accounts, realm string) HandlerFunc {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+	return func(c *Context) {
+		user, password, ok := c.Req.BasicAuth()
+		if !ok || !checkAccount(accounts, user, password) {
+			unauthorized(c, challenge)
+			return
+		}
+		c.SetPrincipal(user)
+		c.Next()
+	}
+}
+
+// checkAccount compares the credentials with every account so the time taken does not
+// reveal whether the user exists.
+func checkAccount(accounts Accounts, user string, password string) bool {
+	userHash := sha256.Sum256([]byte(user))
+	passwordHash := sha256.Sum256([]byte(password))
+	match := 0
+	for accountUser, accountPassword := range accounts {
+		accountUserHash := sha256.Sum256([]byte(accountUser))
+		accountPasswordHash := sha256.Sum256([]byte(accountPassword))
+		match |= subtle.ConstantTimeCompare(userHash[:], accountUserHash[:]) &
+			subtle.ConstantTimeCompare(passwordHash[:], accountPasswordHash[:])
+	}
+	return match == 1
+}
+
+// BearerAuth is a middleware requiring an "Authorization: Bearer" token accepted by
+// validator, whose returned principal is recorded on the Context.
+func BearerAuth(validator BearerValidator) HandlerFunc {
+	return func(c *Context) {
+		token, ok := bearerToken(c.Req)
+		if !ok {
+			unauthorized(c, "Bearer")
+			return
+		}
+		principal, ok := validator(c, token)
+		if !ok {
+			unauthorized(c, `Bearer error="invalid_token"`)
+			return
+		}
+		c.SetPrincipal(principal)
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthorized sends a 401 response with the given WWW-Authenticate challenge.
+func unauthorized(c *Context, challenge string) {
+	c.SetHeader("WWW-Authenticate", challenge)
+	c.Error(http.StatusUnauthorized, "Unauthorized")
+}
@@ -0,0 +1,81 @@
+package tsweb
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveAuth sends a GET request with the given Authorization header.
+func serveAuth(engine *Engine, authorization string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestBasicAuth(t *testing.T) {
+	engine := NewEngine()
+	engine.Use(BasicAuth(Accounts{"tom": "secret", "jerry": "cheese"}, "admin area"))
+	engine.GET("/admin", func(c *Context) {
+		c.String(http.StatusOK, "hello %s", c.Principal())
+	})
+
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	req.SetBasicAuth("tom", "secret")
+	if w := serveAuth(engine, req.Header.Get("Authorization")); w.Code != http.StatusOK || w.Body.String() != "hello tom" {
+		t.Errorf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+	}{
+		{"WrongPassword", "tom", "cheese"},
+		{"UnknownUser", "spike", "secret"},
+		{"Missing", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/admin", nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			w := serveAuth(engine, req.Header.Get("Authorization"))
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); challenge != `Basic realm="admin area", charset="UTF-8"` {
+				t.Errorf("Unexpected challenge: %s", challenge)
+			}
+		})
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	engine := NewEngine()
+	engine.Use(BearerAuth(func(c *Context, token string) (string, bool) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte("api-key-1")) == 1 {
+			return "service-1", true
+		}
+		return "", false
+	}))
+	engine.GET("/admin", func(c *Context) {
+		c.String(http.StatusOK, c.Principal())
+	})
+
+	if w := serveAuth(engine, "Bearer api-key-1"); w.Code != http.StatusOK || w.Body.String() != "service-1" {
+		t.Errorf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if w := serveAuth(engine, ""); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("Expected bearer challenge, got %d %s", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	if w := serveAuth(engine, "Bearer wrong"); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+		t.Errorf("Expected invalid_token challenge, got %d %s", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
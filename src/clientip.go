@@ -0,0 +1,231 @@
+package tsweb
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SetTrustedProxies sets the CIDR ranges (or single IPs) of proxies whose forwarding
+// headers are trusted by ClientIP, Scheme and Host. By default no proxy is trusted.
+func (p *Engine) SetTrustedProxies(proxies []string) error {
+	trusted := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return &net.ParseError{Type: "IP address", Text: proxy}
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxy = ip.String() + "/" + strconv.Itoa(bits)
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return err
+		}
+		trusted = append(trusted, network)
+	}
+	p.trustedProxies = trusted
+	return nil
+}
+
+// isTrustedProxy reports whether ip belongs to a trusted proxy range.
+func (p *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, network := range p.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP address of the direct peer.
+func (p *Context) remoteIP() net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(p.Req.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(p.Req.RemoteAddr)
+	}
+	return net.ParseIP(host)
+}
+
+// fromTrustedProxy reports whether the direct peer is a trusted proxy.
+func (p *Context) fromTrustedProxy() bool {
+	ip := p.remoteIP()
+	return ip != nil && p.engine.isTrustedProxy(ip)
+}
+
+// ClientIP returns the client IP address. When the direct peer is a trusted proxy,
+// X-Forwarded-For, X-Real-IP and the RFC 7239 Forwarded header are consulted in that
+// order, skipping trusted proxies from the right; otherwise the peer address is used.
+func (p *Context) ClientIP() string {
+	remote := p.remoteIP()
+	if remote == nil {
+		return ""
+	}
+	if !p.engine.isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	header := p.Req.Header
+	if chain := splitList(strings.Join(header.Values("X-Forwarded-For"), ",")); len(chain) > 0 {
+		if ip := p.engine.clientFromChain(chain); ip != "" {
+			return ip
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	chain := make([]string, 0)
+	for _, element := range parseForwarded(header) {
+		if value, ok := element["for"]; ok {
+			chain = append(chain, value)
+		}
+	}
+	if ip := p.engine.clientFromChain(chain); ip != "" {
+		return ip
+	}
+	return remote.String()
+}
+
+// clientFromChain walks a forwarding chain from the right and returns the first address
+// that is not a trusted proxy. It returns "" if the chain contains an invalid entry.
+func (p *Engine) clientFromChain(chain []string) string {
+	for index := len(chain) - 1; index >= 0; index-- {
+		ip := parseForwardedIP(chain[index])
+		if ip == nil {
+			return ""
+		}
+		if index == 0 || !p.isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// parseForwardedIP parses an address from X-Forwarded-For or a Forwarded for= value,
+// which may carry brackets and a port.
+func parseForwardedIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	return net.ParseIP(strings.Trim(value, "[]"))
+}
+
+// Scheme returns "https" or "http" for the original request, honoring X-Forwarded-Proto
+// and Forwarded proto= only from trusted proxies.
+func (p *Context) Scheme() string {
+	if p.fromTrustedProxy() {
+		if proto := strings.ToLower(lastListValue(p.Req.Header.Values("X-Forwarded-Proto"))); proto == "http" || proto == "https" {
+			return proto
+		}
+		if proto := strings.ToLower(p.trustedForwarded("proto")); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if p.Req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host of the original request, honoring X-Forwarded-Host and
+// Forwarded host= only from trusted proxies.
+func (p *Context) Host() string {
+	if p.fromTrustedProxy() {
+		if host := lastListValue(p.Req.Header.Values("X-Forwarded-Host")); host != "" {
+			return host
+		}
+		if host := p.trustedForwarded("host"); host != "" {
+			return host
+		}
+	}
+	return p.Req.Host
+}
+
+// lastListValue returns the rightmost item of comma separated header values, which
+// was added by the trusted peer; items further left may come from the client.
+func lastListValue(values []string) string {
+	items := splitList(strings.Join(values, ","))
+	if len(items) == 0 {
+		return ""
+	}
+	return items[len(items)-1]
+}
+
+// trustedForwarded returns the rightmost value of the Forwarded parameter name among
+// the elements added by trusted proxies. Walking from the right like clientFromChain,
+// an element is trusted if it is the last one, added by the trusted peer, or if the
+// element after it was added by a proxy whose for= address is trusted.
+func (p *Context) trustedForwarded(name string) string {
+	elements := parseForwarded(p.Req.Header)
+	for index := len(elements) - 1; index >= 0; index-- {
+		if value := elements[index][name]; value != "" {
+			return value
+		}
+		ip := parseForwardedIP(elements[index]["for"])
+		if ip == nil || !p.engine.isTrustedProxy(ip) {
+			break
+		}
+	}
+	return ""
+}
+
+// splitList splits a comma separated header value, dropping empty items.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseForwarded parses RFC 7239 Forwarded headers into one map per forwarded element,
+// with lower case parameter names and unquoted values.
+func parseForwarded(header http.Header) []map[string]string {
+	elements := make([]map[string]string, 0)
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range splitQuoted(value, ',') {
+			pairs := make(map[string]string)
+			for _, pair := range splitQuoted(element, ';') {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+					value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+				}
+				pairs[strings.ToLower(strings.TrimSpace(key))] = value
+			}
+			if len(pairs) > 0 {
+				elements = append(elements, pairs)
+			}
+		}
+	}
+	return elements
+}
+
+// splitQuoted splits value on separator outside of double quoted strings.
+func splitQuoted(value string, separator byte) []string {
+	parts := make([]string, 0)
+	quoted, start := false, 0
+	for index := 0; index < len(value); index++ {
+		switch {
+		case value[index] == '\\' && quoted:
+			index++
+		case value[index] == '"':
+			quoted = !quoted
+		case value[index] == separator && !quoted:
+			parts = append(parts, value[start:index])
+			start = index + 1
+		}
+	}
+	return append(parts, value[start:])
+}
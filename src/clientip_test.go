@@ -0,0 +1,104 @@
+package tsweb
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+// newClientIPContext creates a Context for a request from remoteAddr with headers.
+func newClientIPContext(engine *Engine, remoteAddr string, headers map[string]string) *Context {
+	req, _ := http.NewRequest("GET", "http://backend.local/test", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return makeContext(nil, req, engine)
+}
+
+func TestContext_ClientIP(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{"Untrusted", "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.9"},
+		{"NoHeaders", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"ForwardedFor", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "1.2.3.4"},
+		{"SkipTrustedHops", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 1.2.3.4, 10.0.0.2, 192.168.1.1"}, "1.2.3.4"},
+		{"AllTrusted", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"InvalidChainFallsBack", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "garbage", "X-Real-IP": "5.6.7.8"}, "5.6.7.8"},
+		{"RealIP", "10.0.0.1:1234", map[string]string{"X-Real-IP": "5.6.7.8"}, "5.6.7.8"},
+		{"Forwarded", "10.0.0.1:1234", map[string]string{"Forwarded": `for="[2001:db9::1]:4711";proto=https, for=10.0.0.2`}, "2001:db9::1"},
+		{"TrustedIPv6Peer", "[2001:db8::1]:443", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "1.2.3.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ip := newClientIPContext(engine, tt.remoteAddr, tt.headers).ClientIP(); ip != tt.expected {
+				t.Errorf("Expected client IP %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestContext_SchemeAndHost(t *testing.T) {
+	engine := NewEngine()
+	engine.SetTrustedProxies([]string{"10.0.0.0/8"})
+	headers := map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "www.example.com"}
+
+	c := newClientIPContext(engine, "10.0.0.1:1234", headers)
+	if c.Scheme() != "https" || c.Host() != "www.example.com" {
+		t.Errorf("Expected forwarded scheme and host, got %s %s", c.Scheme(), c.Host())
+	}
+
+	c = newClientIPContext(engine, "10.0.0.1:1234", map[string]string{"Forwarded": `proto=https;host="api.example.com"`})
+	if c.Scheme() != "https" || c.Host() != "api.example.com" {
+		t.Errorf("Expected Forwarded scheme and host, got %s %s", c.Scheme(), c.Host())
+	}
+
+	c = newClientIPContext(engine, "203.0.113.9:1234", headers)
+	if c.Scheme() != "http" || c.Host() != "backend.local" {
+		t.Errorf("Expected untrusted headers to be ignored, got %s %s", c.Scheme(), c.Host())
+	}
+	c.Req.TLS = &tls.ConnectionState{}
+	if c.Scheme() != "https" {
+		t.Errorf("Expected https for TLS requests, got %s", c.Scheme())
+	}
+}
+
+func TestContext_SchemeAndHostSpoofed(t *testing.T) {
+	engine := NewEngine()
+	engine.SetTrustedProxies([]string{"10.0.0.0/8"})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		scheme  string
+		host    string
+	}{
+		{"ForwardedHostList", map[string]string{"X-Forwarded-Host": "admin.example.com, www.example.com", "X-Forwarded-Proto": "https, http"}, "http", "www.example.com"},
+		{"ForwardedUntrustedHop", map[string]string{"Forwarded": `host=admin.example.com;proto=https, for=1.2.3.4;host=www.example.com`}, "http", "www.example.com"},
+		{"ForwardedClientOnly", map[string]string{"Forwarded": `host=admin.example.com;proto=https, for=1.2.3.4`}, "http", "backend.local"},
+		{"ForwardedTrustedHops", map[string]string{"Forwarded": `for=1.2.3.4, for=5.6.7.8;host=www.example.com;proto=https, for=10.0.0.2`}, "https", "www.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClientIPContext(engine, "10.0.0.1:1234", tt.headers)
+			if c.Scheme() != tt.scheme || c.Host() != tt.host {
+				t.Errorf("Expected %s %s, got %s %s", tt.scheme, tt.host, c.Scheme(), c.Host())
+			}
+		})
+	}
+}
+
+func TestEngine_SetTrustedProxiesInvalid(t *testing.T) {
+	if err := NewEngine().SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for invalid proxy")
+	}
+}
@@ -0,0 +1,46 @@
+package tsweb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// paramConstraints holds the named constraints usable as :name<constraint>.
+var paramConstraints = map[string]string{
+	"int":  `[0-9]+`,
+	"uuid": `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"slug": `[a-z0-9]+(?:-[a-z0-9]+)*`,
+}
+
+// RegisterConstraint registers a named parameter constraint usable as :name<constraint>.
+// It must be called before routes using the constraint are added.
+func RegisterConstraint(name string, expr string) error {
+	if _, err := regexp.Compile(expr); err != nil {
+		return fmt.Errorf("tsweb: invalid constraint %q: %w", name, err)
+	}
+	paramConstraints[name] = expr
+	return nil
+}
+
+// constraintExpr returns the regular expression of a parameter constraint, resolving
+// registered constraints written as <name>. It panics on unknown constraints.
+func constraintExpr(constraint string) string {
+	if !strings.HasPrefix(constraint, "<") {
+		return constraint
+	}
+	expr, ok := paramConstraints[strings.Trim(constraint, "<>")]
+	if !ok {
+		panic(fmt.Sprintf("tsweb: unknown constraint %s", constraint))
+	}
+	return expr
+}
+
+// compileConstraint compiles expr anchored to whole values. It panics on invalid expressions.
+func compileConstraint(expr string) *regexp.Regexp {
+	matcher, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("tsweb: invalid constraint %q: %v", expr, err))
+	}
+	return matcher
+}
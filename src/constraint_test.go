@@ -0,0 +1,79 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConstrainedParams(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/users/:id<int>", func(c *Context) {
+		c.String(http.StatusOK, "id %s", c.Param("id"))
+	})
+	engine.GET("/users/:name<slug>", func(c *Context) {
+		c.String(http.StatusOK, "name %s", c.Param("name"))
+	})
+	engine.GET("/orders/{id:[A-Z]{2}[0-9]+}", func(c *Context) {
+		c.String(http.StatusOK, "order %s", c.Param("id"))
+	})
+	engine.GET("/items/:id<uuid>", func(c *Context) {
+		c.String(http.StatusOK, "item %s", c.Param("id"))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/42", http.StatusOK, "id 42"},
+		{"/users/tom-smith", http.StatusOK, "name tom-smith"},
+		{"/users/Tom_Smith", http.StatusNotFound, "404"},
+		{"/orders/AB123", http.StatusOK, "order AB123"},
+		{"/orders/ab123", http.StatusNotFound, "404"},
+		{"/orders/AB123x", http.StatusNotFound, "404"},
+		{"/items/0b6a8e1c-3c1f-4f6e-9a55-2f1d4b7c9e10", http.StatusOK, "item 0b6a8e1c-3c1f-4f6e-9a55-2f1d4b7c9e10"},
+		{"/items/42", http.StatusNotFound, "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("Expected body '%s', got '%s'", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRegisterConstraint(t *testing.T) {
+	if err := RegisterConstraint("hex", `[0-9a-f]+`); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterConstraint("broken", `[`); err == nil {
+		t.Error("Expected error for invalid constraint expression")
+	}
+
+	engine := NewEngine()
+	engine.GET("/colors/:code<hex>", func(c *Context) {
+		c.String(http.StatusOK, c.Param("code"))
+	}).Name("color")
+
+	if path, err := engine.URL("color", "ff00ff"); err != nil || path != "/colors/ff00ff" {
+		t.Errorf("Expected '/colors/ff00ff', got '%s' (%v)", path, err)
+	}
+	if _, err := engine.URL("color", "red"); err == nil {
+		t.Error("Expected error for value violating the constraint")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unknown constraint")
+		}
+	}()
+	engine.GET("/sizes/:size<unknown>", func(c *Context) {})
+}
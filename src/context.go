@@ -1,31 +1,53 @@
 package tsweb
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"sync"
+	"time"
 )
 
+// defaultMultipartMemory is the maximum number of bytes of a multipart body kept in memory.
+const defaultMultipartMemory = 32 << 20
+
+// defaultMaxBodySize is the default maximum number of bytes read from a request body,
+// matching the limit net/http applies when parsing urlencoded forms.
+const defaultMaxBodySize = 10 << 20
+
 // H is a shorthand for map[string]interface{} used to represent a generic map of data.
 type H map[string]interface{}
 
 // Context represents the context of an HTTP request.
 type Context struct {
-	Writer       http.ResponseWriter // Response writer for sending HTTP response
-	Req          *http.Request       // HTTP request object
-	Path         string              // Request path
-	Method       string              // HTTP method (GET, POST, etc.)
-	Params       map[string]string   // Parameters extracted from the request path
-	StatusCode   int                 // HTTP status code to be sent in the response
-	handle       HandlerFunc         // Handler function for processing the request
-	middlewares  *[]HandlerFunc      // Slice of middleware functions to be executed
-	processIndex int                 // Index to keep track of the current middleware being processed
-	engine       *Engine             // Pointer to the Gee engine instance
+	Writer       http.ResponseWriter    // Response writer for sending HTTP response
+	Req          *http.Request          // HTTP request object
+	Path         string                 // Request path
+	Method       string                 // HTTP method (GET, POST, etc.)
+	Params       map[string]string      // Parameters extracted from the request path
+	StatusCode   int                    // HTTP status code to be sent in the response
+	handle       HandlerFunc            // Handler function for processing the request
+	middlewares  *[]HandlerFunc         // Slice of middleware functions to be executed
+	processIndex int                    // Index to keep track of the current middleware being processed
+	engine       *Engine                // Pointer to the Gee engine instance
+	formParsed   bool                   // Whether the request form has been parsed
+	formErr      error                  // Error returned by the form parse, if any
+	rawData      []byte                 // Cached request body, read at most once
+	rawRead      bool                   // Whether the request body has been read into rawData
+	rawErr       error                  // Error returned by reading the request body, if any
+	session      *Session               // Session loaded by the Sessions middleware
+	csrfToken    string                 // Masked CSRF token set by the CSRF middleware
+	principal    string                 // Principal recorded by an authentication middleware
+	jwtClaims    JWTClaims              // Claims verified by the JWT middleware
+	keys         map[string]interface{} // Per-request values shared between middlewares and handlers
+	keysMutex    sync.RWMutex           // Guards keys
 }
 
 // makeContext creates a new Context object.
 func makeContext(w http.ResponseWriter, r *http.Request, engine *Engine) *Context {
-	r.ParseForm()
 	return &Context{
 		Writer:       w,
 		Req:          r,
@@ -38,18 +60,60 @@ func makeContext(w http.ResponseWriter, r *http.Request, engine *Engine) *Contex
 
 // HTML sends an HTML response with the specified status code, content, and data.
 func (p *Context) HTML(status int, content string, data interface{}) {
-	p.SetHeader("Content-Type", "text/html")
-	p.Status(status)
-	if err := p.engine.htmlTemplates.ExecuteTemplate(p.Writer, content, data); err != nil {
-		p.String(500, err.Error())
-	}
+	p.Render(status, HTMLRender{Template: p.engine.htmlTemplates, Name: content, Data: data})
 }
 
 // String sends a plain text response with the specified status code and formatted content.
 func (p *Context) String(status int, formatString string, contents ...interface{}) {
-	p.SetHeader("Content-Type", "text/plain")
+	p.Render(status, StringRender{Format: formatString, Data: contents})
+}
+
+// XML serializes obj as XML and sends it with the specified status code.
+func (p *Context) XML(status int, obj interface{}) {
+	p.Render(status, XMLRender{Data: obj})
+}
+
+// Render encodes the response with r and sends it with the specified status code.
+// If encoding fails nothing has been written yet and a 500 response is sent instead.
+func (p *Context) Render(status int, r Render) {
+	if aware, ok := r.(engineAware); ok {
+		r = aware.withEngine(p.engine)
+	}
+	body, err := r.Encode()
+	if err != nil {
+		p.Error(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	if contentType := r.ContentType(); contentType != "" {
+		p.SetHeader("Content-Type", contentType)
+	}
 	p.Status(status)
-	p.Writer.Write([]byte(fmt.Sprintf(formatString, contents...)))
+	p.Writer.Write(body)
+}
+
+// Negotiate sends the first of offers whose content type best matches the Accept
+// header, honoring q-values. It responds 406 if no offer is acceptable.
+func (p *Context) Negotiate(status int, offers ...Render) {
+	contentTypes := make([]string, len(offers))
+	for index, offer := range offers {
+		contentTypes[index] = offer.ContentType()
+	}
+	index := negotiateFormat(p.Req.Header.Get("Accept"), contentTypes)
+	if index < 0 {
+		p.Error(http.StatusNotAcceptable, "Not Acceptable")
+		return
+	}
+	p.Render(status, offers[index])
+}
+
+// NegotiateFormat returns the offered content type that best matches the Accept
+// header, or "" if none is acceptable.
+func (p *Context) NegotiateFormat(offered ...string) string {
+	index := negotiateFormat(p.Req.Header.Get("Accept"), offered)
+	if index < 0 {
+		return ""
+	}
+	return offered[index]
 }
 
 // Query returns the value of the specified query parameter from the request URL.
@@ -58,10 +122,78 @@ func (p *Context) Query(key string) string {
 }
 
 // PostForm returns the value of the specified form parameter from the HTTP POST body.
+// The form is parsed on first access; use ParseForm to inspect parse errors.
 func (p *Context) PostForm(key string) string {
+	p.ParseForm()
 	return p.Req.FormValue(key)
 }
 
+// ParseForm parses the request form once and returns the parse error, if any.
+// Non-multipart bodies are cached first so GetRawData still works afterwards;
+// multipart bodies are streamed to ParseMultipartForm and cannot be re-read.
+func (p *Context) ParseForm() error {
+	if p.formParsed {
+		return p.formErr
+	}
+	p.formParsed = true
+
+	mediaType, _, _ := mime.ParseMediaType(p.Req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		p.formErr = p.Req.ParseMultipartForm(defaultMultipartMemory)
+		return p.formErr
+	}
+	if _, err := p.GetRawData(); err != nil {
+		p.formErr = err
+		return p.formErr
+	}
+	p.formErr = p.Req.ParseForm()
+	p.resetBody()
+	return p.formErr
+}
+
+// GetRawData reads and returns the request body. The body is cached so it can be
+// read again by later calls, by ParseForm and by binders such as BindJSON. Bodies
+// larger than the engine's maximum body size fail with an *http.MaxBytesError.
+func (p *Context) GetRawData() ([]byte, error) {
+	if !p.rawRead {
+		p.rawRead = true
+		if p.Req.Body != nil {
+			limit := int64(defaultMaxBodySize)
+			if p.engine != nil {
+				limit = p.engine.maxBodySize
+			}
+			data, err := io.ReadAll(http.MaxBytesReader(p.Writer, p.Req.Body, limit))
+			p.Req.Body.Close()
+			if err != nil {
+				p.rawErr = err
+			} else {
+				p.rawData = data
+			}
+		}
+	}
+	p.resetBody()
+	if p.rawErr != nil {
+		return nil, p.rawErr
+	}
+	return p.rawData, nil
+}
+
+// BindJSON decodes the JSON request body into obj.
+func (p *Context) BindJSON(obj interface{}) error {
+	data, err := p.GetRawData()
+	if err != nil {
+		return err
+	}
+	return p.engine.jsonCodec.Unmarshal(data, obj)
+}
+
+// resetBody rewinds Req.Body to the cached body so it can be consumed again.
+func (p *Context) resetBody() {
+	if p.rawRead {
+		p.Req.Body = io.NopCloser(bytes.NewReader(p.rawData))
+	}
+}
+
 // Status sets the HTTP status code for the response.
 func (p *Context) Status(code int) {
 	p.StatusCode = code
@@ -75,13 +207,16 @@ func (p *Context) SetHeader(key string, value string) {
 
 // Data sends raw data with the specified status code.
 func (p *Context) Data(status int, data []byte) {
-	p.Status(status)
-	p.Writer.Write(data)
+	p.Render(status, DataRender{Data: data})
 }
 
 // Param returns the value of the specified path parameter from the request.
+// With UsePathValues, parameters set by an enclosing ServeMux are read from the request.
 func (p *Context) Param(key string) string {
-	return p.Params[key]
+	if value, ok := p.Params[key]; ok || p.engine == nil || !p.engine.usePathValues {
+		return value
+	}
+	return p.Req.PathValue(key)
 }
 
 // Next proceeds to the next middleware in the chain.
@@ -94,24 +229,149 @@ func (p *Context) Next() {
 	}
 }
 
-// JSON sends a JSON response with the specified status code and header data.
-func (p *Context) JSON(status int, header map[string]interface{}) {
-	p.SetHeader("Content-Type", "application/json")
-	p.Status(status)
-	value, err := json.Marshal(header)
-	if err != nil {
-		p.Error(http.StatusInternalServerError, "Internal Server Error")
+// JSON serializes obj as JSON and sends it with the specified status code.
+// The value is marshalled before any header is written, so an encoding failure
+// results in a clean 500 response.
+func (p *Context) JSON(status int, obj interface{}) {
+	p.Render(status, JSONRender{Data: obj})
+}
+
+// IndentedJSON serializes obj as pretty-printed JSON and sends it with the specified status code.
+func (p *Context) IndentedJSON(status int, obj interface{}) {
+	p.Render(status, IndentedJSONRender{Data: obj})
+}
+
+// SecureJSON serializes obj as JSON prefixed with the Engine's secure JSON prefix
+// to prevent JSON hijacking.
+func (p *Context) SecureJSON(status int, obj interface{}) {
+	p.Render(status, SecureJSONRender{Data: obj})
+}
+
+// JSONP serializes obj as JSON wrapped in the callback named by the "callback" query
+// parameter. Without a callback it behaves like JSON; an invalid callback yields 400.
+func (p *Context) JSONP(status int, obj interface{}) {
+	callback := p.Query("callback")
+	if callback == "" {
+		p.JSON(status, obj)
 		return
 	}
-	_, err = p.Writer.Write(value)
-	if err != nil {
-		// Failed to write response
-		p.Error(http.StatusInternalServerError, "Internal Server Error")
+	if !isValidJSONPCallback(callback) {
+		p.Error(http.StatusBadRequest, "Invalid JSONP callback")
 		return
 	}
+	p.Render(status, JSONPRender{Callback: callback, Data: obj})
+}
+
+// AsciiJSON serializes obj as JSON with all non-ASCII characters escaped.
+func (p *Context) AsciiJSON(status int, obj interface{}) {
+	p.Render(status, AsciiJSONRender{Data: obj})
 }
 
 // Error sends an error response with the specified status code and message.
 func (p *Context) Error(status int, message string) {
 	http.Error(p.Writer, message, status)
 }
+
+// Set stores a value for the lifetime of the request under key.
+func (p *Context) Set(key string, value interface{}) {
+	p.keysMutex.Lock()
+	defer p.keysMutex.Unlock()
+	if p.keys == nil {
+		p.keys = make(map[string]interface{})
+	}
+	p.keys[key] = value
+}
+
+// Get returns the value stored under key and whether it exists.
+func (p *Context) Get(key string) (interface{}, bool) {
+	p.keysMutex.RLock()
+	defer p.keysMutex.RUnlock()
+	value, ok := p.keys[key]
+	return value, ok
+}
+
+// MustGet returns the value stored under key and panics if it does not exist.
+func (p *Context) MustGet(key string) interface{} {
+	value, ok := p.Get(key)
+	if !ok {
+		panic(fmt.Sprintf("tsweb: key %q does not exist", key))
+	}
+	return value
+}
+
+// GetString returns the value stored under key as a string, or "" if it is not one.
+func (p *Context) GetString(key string) string {
+	value, _ := p.Get(key)
+	s, _ := value.(string)
+	return s
+}
+
+// GetInt returns the value stored under key as an int, or 0 if it is not one.
+func (p *Context) GetInt(key string) int {
+	value, _ := p.Get(key)
+	i, _ := value.(int)
+	return i
+}
+
+// Copy returns a copy of the Context that can be used by another goroutine. The copy
+// has its own key map; the request and response writer are shared.
+func (p *Context) Copy() *Context {
+	p.keysMutex.RLock()
+	keys := make(map[string]interface{}, len(p.keys))
+	for key, value := range p.keys {
+		keys[key] = value
+	}
+	p.keysMutex.RUnlock()
+
+	return &Context{
+		Writer:       p.Writer,
+		Req:          p.Req,
+		Path:         p.Path,
+		Method:       p.Method,
+		Params:       p.Params,
+		StatusCode:   p.StatusCode,
+		handle:       p.handle,
+		middlewares:  p.middlewares,
+		processIndex: p.processIndex,
+		engine:       p.engine,
+		formParsed:   p.formParsed,
+		formErr:      p.formErr,
+		rawData:      p.rawData,
+		rawRead:      p.rawRead,
+		rawErr:       p.rawErr,
+		session:      p.session,
+		csrfToken:    p.csrfToken,
+		principal:    p.principal,
+		jwtClaims:    p.jwtClaims,
+		keys:         keys,
+	}
+}
+
+// Deadline returns the deadline of the request context.
+func (p *Context) Deadline() (time.Time, bool) {
+	return p.Req.Context().Deadline()
+}
+
+// Done returns the done channel of the request context.
+func (p *Context) Done() <-chan struct{} {
+	return p.Req.Context().Done()
+}
+
+// Err returns the error of the request context.
+func (p *Context) Err() error {
+	return p.Req.Context().Err()
+}
+
+// Value returns the value stored with Set for string keys and falls back to the
+// request context for everything else.
+func (p *Context) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value, exists := p.Get(name); exists {
+			return value
+		}
+	}
+	return p.Req.Context().Value(key)
+}
+
+// Context implements context.Context so it can be passed to context aware APIs directly.
+var _ context.Context = (*Context)(nil)
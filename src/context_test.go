@@ -1,8 +1,11 @@
 package tsweb
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -95,3 +98,126 @@ func TestContext_Error(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 }
+
+func TestContext_ParseFormLazy(t *testing.T) {
+	body := "username=tom&password=secret"
+	req, _ := http.NewRequest("POST", "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	engine := NewEngine()
+	c := makeContext(nil, req, engine)
+
+	// The body must not be consumed until the form is accessed
+	if req.PostForm != nil {
+		t.Fatal("Expected form to be parsed lazily")
+	}
+
+	if value := c.PostForm("username"); value != "tom" {
+		t.Errorf("Expected value 'tom', got '%s'", value)
+	}
+
+	// The raw body is still available after the form has been parsed
+	data, err := c.GetRawData()
+	if err != nil || string(data) != body {
+		t.Errorf("Expected raw body '%s', got '%s' (%v)", body, data, err)
+	}
+}
+
+func TestContext_ParseFormError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/login", strings.NewReader("a=%zz"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	engine := NewEngine()
+	c := makeContext(nil, req, engine)
+
+	if err := c.ParseForm(); err == nil {
+		t.Error("Expected parse error for malformed form body")
+	}
+}
+
+func TestContext_GetRawDataReuse(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/test", strings.NewReader(`{"name":"tom"}`))
+	engine := NewEngine()
+	c := makeContext(nil, req, engine)
+
+	var first, second struct{ Name string }
+	if err := c.BindJSON(&first); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BindJSON(&second); err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != "tom" || second.Name != "tom" {
+		t.Errorf("Expected both binds to read 'tom', got '%s' and '%s'", first.Name, second.Name)
+	}
+}
+
+func TestContext_MaxBodySize(t *testing.T) {
+	engine := NewEngine()
+	engine.SetMaxBodySize(8)
+
+	req, _ := http.NewRequest("POST", "/test", strings.NewReader("name="+strings.Repeat("a", 100)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := makeContext(httptest.NewRecorder(), req, engine)
+
+	var tooLarge *http.MaxBytesError
+	if err := c.ParseForm(); !errors.As(err, &tooLarge) {
+		t.Errorf("Expected MaxBytesError from ParseForm, got %v", err)
+	}
+	// A failed read must keep failing instead of returning an empty body
+	for i := 0; i < 2; i++ {
+		if data, err := c.GetRawData(); !errors.As(err, &tooLarge) || data != nil {
+			t.Errorf("Expected MaxBytesError from GetRawData, got %q, %v", data, err)
+		}
+	}
+
+	req, _ = http.NewRequest("POST", "/test", strings.NewReader("name=tom"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c = makeContext(httptest.NewRecorder(), req, engine)
+	if c.PostForm("name") != "tom" {
+		t.Errorf("Expected body within the limit to be parsed, got '%s'", c.PostForm("name"))
+	}
+}
+
+func TestContext_Keys(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(nil, req, NewEngine())
+
+	c.Set("user", "tom")
+	c.Set("age", 10)
+	if value, ok := c.Get("user"); !ok || value != "tom" {
+		t.Errorf("Expected value 'tom', got '%v'", value)
+	}
+	if c.GetString("user") != "tom" || c.GetInt("age") != 10 {
+		t.Errorf("Unexpected typed values: %s %d", c.GetString("user"), c.GetInt("age"))
+	}
+	if c.GetString("age") != "" || c.GetInt("missing") != 0 {
+		t.Error("Expected zero values for mismatched or missing keys")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustGet to panic for a missing key")
+		}
+	}()
+	c.MustGet("missing")
+}
+
+// contextKey is a private key type for request context values.
+type contextKey struct{}
+
+func TestContext_ContextInterface(t *testing.T) {
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "from request"))
+	req, _ := http.NewRequestWithContext(parent, "GET", "/test", nil)
+	c := makeContext(nil, req, NewEngine())
+	c.Set("user", "tom")
+
+	var ctx context.Context = c
+	if ctx.Value("user") != "tom" || ctx.Value(contextKey{}) != "from request" {
+		t.Errorf("Unexpected values: %v %v", ctx.Value("user"), ctx.Value(contextKey{}))
+	}
+
+	cancel()
+	<-ctx.Done()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", ctx.Err())
+	}
+}
@@ -0,0 +1,239 @@
+package tsweb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidCookie is returned when a signed or encrypted cookie fails verification.
+var ErrInvalidCookie = errors.New("tsweb: invalid cookie")
+
+// errNoCookieKeys is returned when signed or encrypted cookies are used without keys.
+var errNoCookieKeys = errors.New("tsweb: no cookie keys configured")
+
+// CookieOptions holds the attributes applied to cookies set through a Context.
+type CookieOptions struct {
+	Path     string        // Path attribute, defaults to "/"
+	Domain   string        // Domain attribute
+	MaxAge   int           // Max-Age in seconds; zero means a session cookie, negative deletes
+	Secure   bool          // Secure attribute
+	HttpOnly bool          // HttpOnly attribute
+	SameSite http.SameSite // SameSite attribute
+}
+
+// defaultCookieOptions returns the options used when the Engine has not been configured.
+func defaultCookieOptions() CookieOptions {
+	return CookieOptions{
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// cookieKey holds the keys derived from a configured cookie secret.
+type cookieKey struct {
+	signKey []byte      // HMAC-SHA256 key used for signed cookies
+	aead    cipher.AEAD // AES-256-GCM cipher used for encrypted cookies
+}
+
+// newCookieKey derives independent signing and encryption keys from secret.
+func newCookieKey(secret []byte) (cookieKey, error) {
+	if len(secret) < 16 {
+		return cookieKey{}, errors.New("tsweb: cookie key must be at least 16 bytes")
+	}
+	block, err := aes.NewCipher(deriveKey(secret, "tsweb cookie encryption"))
+	if err != nil {
+		return cookieKey{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return cookieKey{}, err
+	}
+	return cookieKey{signKey: deriveKey(secret, "tsweb cookie signing"), aead: aead}, nil
+}
+
+// deriveKey derives a 32 byte key for purpose from secret.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// sign returns the HMAC of the cookie name and value.
+func (k cookieKey) sign(name string, value []byte) []byte {
+	mac := hmac.New(sha256.New, k.signKey)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(value)
+	return mac.Sum(nil)
+}
+
+// SetCookieOptions sets the default attributes for cookies written by SetCookie.
+func (p *Engine) SetCookieOptions(options CookieOptions) {
+	p.cookieOptions = options
+}
+
+// SetCookieKeys configures the secrets used for signed and encrypted cookies. The first
+// key signs and encrypts new cookies; all keys are accepted when reading, which allows
+// keys to be rotated without invalidating existing cookies.
+func (p *Engine) SetCookieKeys(keys ...[]byte) error {
+	cookieKeys := make([]cookieKey, 0, len(keys))
+	for _, secret := range keys {
+		key, err := newCookieKey(secret)
+		if err != nil {
+			return err
+		}
+		cookieKeys = append(cookieKeys, key)
+	}
+	p.cookieKeys = cookieKeys
+	return nil
+}
+
+// Cookie returns the unescaped value of the named request cookie.
+func (p *Context) Cookie(name string) (string, error) {
+	cookie, err := p.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
+// SetCookie sets a cookie using the Engine's default cookie options.
+func (p *Context) SetCookie(name string, value string) {
+	p.SetCookieWithOptions(name, value, p.engine.cookieOptions)
+}
+
+// SetCookieWithOptions sets a cookie with explicit attributes. The value is escaped.
+func (p *Context) SetCookieWithOptions(name string, value string, options CookieOptions) {
+	if options.Path == "" {
+		options.Path = "/"
+	}
+	http.SetCookie(p.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     options.Path,
+		Domain:   options.Domain,
+		MaxAge:   options.MaxAge,
+		Secure:   options.Secure,
+		HttpOnly: options.HttpOnly,
+		SameSite: options.SameSite,
+	})
+}
+
+// DeleteCookie expires the named cookie using the Engine's default path and domain.
+func (p *Context) DeleteCookie(name string) {
+	options := p.engine.cookieOptions
+	options.MaxAge = -1
+	p.SetCookieWithOptions(name, "", options)
+}
+
+// SetSignedCookie sets a cookie whose value is readable by the client but protected
+// against tampering with an HMAC.
+func (p *Context) SetSignedCookie(name string, value string) error {
+	encoded, err := p.engine.signCookie(name, value)
+	if err != nil {
+		return err
+	}
+	p.SetCookie(name, encoded)
+	return nil
+}
+
+// SignedCookie returns the verified value of a cookie set by SetSignedCookie.
+func (p *Context) SignedCookie(name string) (string, error) {
+	value, err := p.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return p.engine.verifyCookie(name, value)
+}
+
+// SetEncryptedCookie sets a cookie whose value is encrypted and authenticated with AES-GCM.
+func (p *Context) SetEncryptedCookie(name string, value string) error {
+	encoded, err := p.engine.encryptCookie(name, value)
+	if err != nil {
+		return err
+	}
+	p.SetCookie(name, encoded)
+	return nil
+}
+
+// EncryptedCookie returns the decrypted value of a cookie set by SetEncryptedCookie.
+func (p *Context) EncryptedCookie(name string) (string, error) {
+	value, err := p.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return p.engine.decryptCookie(name, value)
+}
+
+// signCookie encodes value with an HMAC signature made with the current key.
+func (p *Engine) signCookie(name string, value string) (string, error) {
+	if len(p.cookieKeys) == 0 {
+		return "", errNoCookieKeys
+	}
+	signature := p.cookieKeys[0].sign(name, []byte(value))
+	return base64.RawURLEncoding.EncodeToString([]byte(value)) + "." +
+		base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyCookie checks a value produced by signCookie against every configured key.
+func (p *Engine) verifyCookie(name string, encoded string) (string, error) {
+	encodedValue, encodedSignature, ok := strings.Cut(encoded, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	value, err := base64.RawURLEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range p.cookieKeys {
+		if hmac.Equal(signature, key.sign(name, value)) {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// encryptCookie encrypts value with the current key, binding it to the cookie name.
+func (p *Engine) encryptCookie(name string, value string) (string, error) {
+	if len(p.cookieKeys) == 0 {
+		return "", errNoCookieKeys
+	}
+	aead := p.cookieKeys[0].aead
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decryptCookie decrypts a value produced by encryptCookie with any configured key.
+func (p *Engine) decryptCookie(name string, encoded string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range p.cookieKeys {
+		nonceSize := key.aead.NonceSize()
+		if len(sealed) < nonceSize {
+			return "", ErrInvalidCookie
+		}
+		if value, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
@@ -0,0 +1,101 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// cookieRoundTrip copies the cookies set on w into a new request to path.
+func cookieRoundTrip(w *httptest.ResponseRecorder, path string) *http.Request {
+	req, _ := http.NewRequest("GET", path, nil)
+	for _, cookie := range w.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestContext_SetCookie(t *testing.T) {
+	engine := NewEngine()
+	engine.SetCookieOptions(CookieOptions{Path: "/app", Secure: true, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	makeContext(w, req, engine).SetCookie("name", "tom & jerry")
+
+	header := w.Header().Get("Set-Cookie")
+	for _, attribute := range []string{"Path=/app", "Secure", "HttpOnly", "SameSite=Strict"} {
+		if !strings.Contains(header, attribute) {
+			t.Errorf("Expected %s in Set-Cookie header %s", attribute, header)
+		}
+	}
+
+	c := makeContext(nil, cookieRoundTrip(w, "/app"), engine)
+	if value, err := c.Cookie("name"); err != nil || value != "tom & jerry" {
+		t.Errorf("Expected cookie value 'tom & jerry', got '%s' (%v)", value, err)
+	}
+	if _, err := c.Cookie("missing"); err != http.ErrNoCookie {
+		t.Errorf("Expected http.ErrNoCookie, got %v", err)
+	}
+}
+
+func TestContext_SignedCookie(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.SetCookieKeys([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	if err := makeContext(w, req, engine).SetSignedCookie("user", "tom"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := makeContext(nil, cookieRoundTrip(w, "/"), engine)
+	if value, err := c.SignedCookie("user"); err != nil || value != "tom" {
+		t.Errorf("Expected signed cookie 'tom', got '%s' (%v)", value, err)
+	}
+
+	// A tampered value must be rejected
+	tampered, _ := http.NewRequest("GET", "/", nil)
+	raw, _ := c.Cookie("user")
+	tampered.AddCookie(&http.Cookie{Name: "user", Value: "YWRtaW4" + raw[strings.Index(raw, "."):]})
+	if _, err := makeContext(nil, tampered, engine).SignedCookie("user"); err != ErrInvalidCookie {
+		t.Errorf("Expected ErrInvalidCookie, got %v", err)
+	}
+}
+
+func TestContext_EncryptedCookieKeyRotation(t *testing.T) {
+	oldKey, newKey := []byte("old-secret-key-0123"), []byte("new-secret-key-0123")
+	engine := NewEngine()
+	engine.SetCookieKeys(oldKey)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	if err := makeContext(w, req, engine).SetEncryptedCookie("session", "secret data"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(w.Header().Get("Set-Cookie"), "secret") {
+		t.Error("Expected cookie value to be encrypted")
+	}
+
+	// After rotation the old key is still accepted for reading
+	engine.SetCookieKeys(newKey, oldKey)
+	c := makeContext(nil, cookieRoundTrip(w, "/"), engine)
+	if value, err := c.EncryptedCookie("session"); err != nil || value != "secret data" {
+		t.Errorf("Expected decrypted value 'secret data', got '%s' (%v)", value, err)
+	}
+
+	// Once the old key is dropped the cookie is no longer valid
+	engine.SetCookieKeys(newKey)
+	if _, err := c.EncryptedCookie("session"); err != ErrInvalidCookie {
+		t.Errorf("Expected ErrInvalidCookie, got %v", err)
+	}
+}
+
+func TestEngine_SetCookieKeysTooShort(t *testing.T) {
+	if err := NewEngine().SetCookieKeys([]byte("short")); err == nil {
+		t.Error("Expected error for short cookie key")
+	}
+}
@@ -0,0 +1,125 @@
+package tsweb
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS middleware.
+type CORSConfig struct {
+	AllowOrigins     []string                 // Allowed origins; "*" allows any, "https://*.example.com" allows subdomains
+	AllowOriginFunc  func(origin string) bool // Optional predicate consulted when no entry of AllowOrigins matches
+	AllowMethods     []string                 // Methods allowed in preflights, defaults to common methods
+	AllowHeaders     []string                 // Request headers allowed in preflights, defaults to common headers
+	ExposeHeaders    []string                 // Response headers exposed to scripts
+	AllowCredentials bool                     // Whether cookies and authorization headers are allowed
+	MaxAge           time.Duration            // How long preflight results may be cached
+}
+
+// withDefaults returns a copy of the config with default values filled in.
+func (config CORSConfig) withDefaults() CORSConfig {
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	}
+	if len(config.AllowHeaders) == 0 {
+		config.AllowHeaders = []string{"Origin", "Accept", "Content-Type", "Authorization", "X-Requested-With"}
+	}
+	return config
+}
+
+// allowOrigin reports whether origin is allowed by the config.
+func (config CORSConfig) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range config.AllowOrigins {
+		if matchOrigin(strings.ToLower(pattern), origin) {
+			return true
+		}
+	}
+	return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+}
+
+// allowsAnyOrigin reports whether the config contains the "*" origin.
+func (config CORSConfig) allowsAnyOrigin() bool {
+	for _, pattern := range config.AllowOrigins {
+		if pattern == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin matches origin against pattern, where a single "*" matches a non-empty run.
+func matchOrigin(pattern string, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == origin
+	}
+	return len(origin) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
+
+// CORS is a middleware implementing cross-origin resource sharing. Preflight requests
+// are answered directly with 204, even when no OPTIONS route is registered. It panics
+// if the "*" origin is combined with AllowCredentials, which would let any site make
+// credentialed requests and read the responses.
+func CORS(config CORSConfig) HandlerFunc {
+	config = config.withDefaults()
+	if config.allowsAnyOrigin() && config.AllowCredentials {
+		panic("tsweb: CORS origin \"*\" cannot be combined with AllowCredentials")
+	}
+	allowMethods := strings.Join(config.AllowMethods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	anyOrigin := config.allowsAnyOrigin()
+
+	return func(c *Context) {
+		origin := c.Req.Header.Get("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		preflight := c.Method == http.MethodOptions && c.Req.Header.Get("Access-Control-Request-Method") != ""
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		if !config.allowOrigin(origin) {
+			if preflight {
+				c.Status(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		header.Set("Access-Control-Allow-Headers", allowHeaders)
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge/time.Second)))
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,148 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCORSTestEngine creates an engine with a CORS protected API group.
+func newCORSTestEngine(config CORSConfig) *Engine {
+	engine := NewEngine()
+	api := engine.Group("/api")
+	api.Use(CORS(config))
+	api.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "user %s", c.Param("id"))
+	})
+	api.POST("/users", func(c *Context) {
+		c.String(http.StatusCreated, "created")
+	})
+	return engine
+}
+
+// serveCORS sends a request with an Origin and optional preflight method.
+func serveCORS(engine *Engine, method string, path string, origin string, requestMethod string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	if requestMethod != "" {
+		req.Header.Set("Access-Control-Request-Method", requestMethod)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	engine := newCORSTestEngine(CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	// No OPTIONS route is registered, the preflight is still answered
+	w := serveCORS(engine, "OPTIONS", "/api/users", "https://app.example.com", "POST")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	}
+	for key, value := range expected {
+		if w.Header().Get(key) != value {
+			t.Errorf("Expected %s %s, got %s", key, value, w.Header().Get(key))
+		}
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("Expected POST in allowed methods, got %s", w.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	// Disallowed origins are rejected
+	if w := serveCORS(engine, "OPTIONS", "/api/users", "https://example.org", "POST"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestCORS_SimpleRequest(t *testing.T) {
+	engine := newCORSTestEngine(CORSConfig{
+		AllowOrigins:    []string{"*"},
+		AllowOriginFunc: func(origin string) bool { return false },
+		ExposeHeaders:   []string{"X-Total-Count"},
+	})
+
+	w := serveCORS(engine, "GET", "/api/users/1", "https://any.site", "")
+	if w.Code != http.StatusOK || w.Body.String() != "user 1" {
+		t.Fatalf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Expose-Headers") != "X-Total-Count" {
+		t.Errorf("Unexpected CORS headers: %v", w.Header())
+	}
+}
+
+func TestCORS_OriginFunc(t *testing.T) {
+	engine := newCORSTestEngine(CORSConfig{
+		AllowOriginFunc: func(origin string) bool { return strings.HasSuffix(origin, ".internal") },
+	})
+
+	if w := serveCORS(engine, "GET", "/api/users/1", "http://tool.internal", ""); w.Header().Get("Access-Control-Allow-Origin") != "http://tool.internal" {
+		t.Errorf("Expected origin to be allowed by predicate, got %v", w.Header())
+	}
+	if w := serveCORS(engine, "GET", "/api/users/1", "http://evil.com", ""); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected origin to be rejected, got %v", w.Header())
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		match   bool
+	}{
+		{"*", "https://a.com", true},
+		{"https://a.com", "https://a.com", true},
+		{"https://*.a.com", "https://x.a.com", true},
+		{"https://*.a.com", "https://.a.com", false},
+		{"https://*.a.com", "https://a.com", false},
+		{"https://*.a.com", "http://x.a.com", false},
+	}
+	for _, tt := range tests {
+		if matchOrigin(tt.pattern, tt.origin) != tt.match {
+			t.Errorf("matchOrigin(%q, %q) expected %v", tt.pattern, tt.origin, tt.match)
+		}
+	}
+}
+
+func TestCORS_AnyOriginWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for \"*\" origin with credentials")
+		}
+	}()
+	CORS(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestCORS_PreflightAcrossGroups(t *testing.T) {
+	engine := NewEngine()
+	engine.Group("/api").GET("/items", func(c *Context) {})
+	protected := engine.Group("/api")
+	protected.Use(CORS(CORSConfig{AllowOrigins: []string{"https://app.example.com"}}))
+	protected.POST("/items", func(c *Context) {})
+
+	// The preflight must run the middleware of the POST route, not of the GET route sorted first
+	w := serveCORS(engine, "OPTIONS", "/api/items", "https://app.example.com", "POST")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "https://app.example.com" {
+		t.Errorf("Expected Access-Control-Allow-Origin from the POST group, got '%s'", origin)
+	}
+
+	// Without a route for the requested method the first match is used
+	w = serveCORS(engine, "OPTIONS", "/api/items", "https://app.example.com", "DELETE")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected plain OPTIONS reply from the GET group, got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,189 @@
+package tsweb
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// csrfTokenLength is the number of random bytes in a CSRF token.
+const csrfTokenLength = 32
+
+// csrfSessionKey is the session key holding the token in synchronizer token mode.
+const csrfSessionKey = "_csrf"
+
+// CSRFConfig configures the CSRF middleware. Zero fields fall back to defaults.
+type CSRFConfig struct {
+	UseSession   bool        // Store the token in the session (requires Sessions) instead of a cookie
+	CookieName   string      // Cookie holding the token in double-submit mode, defaults to "_csrf"
+	FieldName    string      // Form field carrying the submitted token, defaults to "_csrf"
+	HeaderName   string      // Header carrying the submitted token, defaults to "X-CSRF-Token"
+	ExemptPaths  []string    // Paths skipped by the check; a trailing "*" matches a prefix
+	ErrorHandler HandlerFunc // Called when the check fails, defaults to a 403 response
+}
+
+// withDefaults returns a copy of the config with default values filled in.
+func (config CSRFConfig) withDefaults() CSRFConfig {
+	if config.CookieName == "" {
+		config.CookieName = "_csrf"
+	}
+	if config.FieldName == "" {
+		config.FieldName = "_csrf"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *Context) {
+			c.Error(http.StatusForbidden, "Forbidden: invalid CSRF token")
+		}
+	}
+	return config
+}
+
+// isExempt reports whether path is excluded from the CSRF check.
+func (config CSRFConfig) isExempt(path string) bool {
+	for _, exempt := range config.ExemptPaths {
+		if prefix, ok := strings.CutSuffix(exempt, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == exempt {
+			return true
+		}
+	}
+	return false
+}
+
+// loadToken returns the stored token for the request, or nil if there is none.
+func (config CSRFConfig) loadToken(c *Context) []byte {
+	var encoded string
+	if config.UseSession {
+		encoded, _ = c.Session().Get(csrfSessionKey).(string)
+	} else {
+		encoded, _ = c.Cookie(config.CookieName)
+	}
+	token, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(token) != csrfTokenLength {
+		return nil
+	}
+	return token
+}
+
+// saveToken stores a newly generated token in the session or the token cookie.
+func (config CSRFConfig) saveToken(c *Context, token []byte) error {
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+	if config.UseSession {
+		c.Session().Set(csrfSessionKey, encoded)
+		return c.Session().Save()
+	}
+	// The cookie stays readable by scripts so AJAX clients can echo it in the header
+	options := c.engine.cookieOptions
+	options.HttpOnly = false
+	c.SetCookieWithOptions(config.CookieName, encoded, options)
+	return nil
+}
+
+// CSRF is a middleware protecting unsafe methods against cross-site request forgery.
+// It uses synchronizer tokens when UseSession is set and double-submit cookies otherwise.
+// Handlers obtain the token for forms and AJAX clients through Context.CSRFToken. With
+// UseSession, requests panic unless Sessions runs before CSRF.
+func CSRF(config CSRFConfig) HandlerFunc {
+	config = config.withDefaults()
+	return func(c *Context) {
+		if config.UseSession && c.Session() == nil {
+			panic("tsweb: CSRF UseSession requires the Sessions middleware")
+		}
+		token := config.loadToken(c)
+		if token == nil {
+			token = make([]byte, csrfTokenLength)
+			if _, err := rand.Read(token); err != nil {
+				c.Error(http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			if err := config.saveToken(c, token); err != nil {
+				c.Error(http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+		}
+		c.csrfToken = maskCSRFToken(token)
+
+		if isSafeMethod(c.Method) || config.isExempt(c.Path) {
+			c.Next()
+			return
+		}
+		submitted := c.Req.Header.Get(config.HeaderName)
+		if submitted == "" {
+			submitted = c.PostForm(config.FieldName)
+		}
+		if !verifyCSRFToken(token, submitted) {
+			config.ErrorHandler(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CSRFToken returns a masked CSRF token for the current request, or "" if the CSRF
+// middleware is not installed. A fresh mask is used per request to resist BREACH.
+func (p *Context) CSRFToken() string {
+	return p.csrfToken
+}
+
+// CSRFFuncMap returns template functions for use with SetFuncMap. csrfField renders
+// a hidden input carrying the token passed to it, e.g. {{ csrfField .csrf }}.
+func CSRFFuncMap(config CSRFConfig) template.FuncMap {
+	config = config.withDefaults()
+	return template.FuncMap{
+		"csrfField": func(token string) string {
+			return `<input type="hidden" name="` + html.EscapeString(config.FieldName) +
+				`" value="` + html.EscapeString(token) + `">`
+		},
+	}
+}
+
+// isSafeMethod reports whether method is exempt from CSRF checks by RFC 7231.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// maskCSRFToken XORs token with a random one-time pad and returns pad and result encoded.
+func maskCSRFToken(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	pad := masked[:len(token)]
+	if _, err := rand.Read(pad); err != nil {
+		panic(err)
+	}
+	for index, b := range token {
+		masked[len(token)+index] = b ^ pad[index]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// verifyCSRFToken compares submitted with token in constant time. Masked tokens are
+// unmasked first; unmasked tokens are accepted as read from the double-submit cookie.
+func verifyCSRFToken(token []byte, submitted string) bool {
+	masked, err := base64.RawURLEncoding.DecodeString(submitted)
+	if err != nil {
+		return false
+	}
+	if len(masked) == len(token) {
+		return subtle.ConstantTimeCompare(masked, token) == 1
+	}
+	if len(masked) != 2*len(token) {
+		return false
+	}
+	unmasked := make([]byte, len(token))
+	for index := range unmasked {
+		unmasked[index] = masked[index] ^ masked[len(token)+index]
+	}
+	return subtle.ConstantTimeCompare(unmasked, token) == 1
+}
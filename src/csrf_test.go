@@ -0,0 +1,120 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCSRFTestEngine creates an engine with a form and a protected login route.
+func newCSRFTestEngine(config CSRFConfig, middlewares ...HandlerFunc) *Engine {
+	engine := NewEngine()
+	for _, middleware := range middlewares {
+		engine.Use(middleware)
+	}
+	engine.Use(CSRF(config))
+	engine.GET("/form", func(c *Context) {
+		c.String(http.StatusOK, c.CSRFToken())
+	})
+	engine.POST("/login", func(c *Context) {
+		c.String(http.StatusOK, "welcome")
+	})
+	engine.POST("/webhook/github", func(c *Context) {
+		c.String(http.StatusOK, "hook")
+	})
+	return engine
+}
+
+// postForm sends a urlencoded POST request with the given cookies and headers.
+func postForm(engine *Engine, path string, form url.Values, cookies []*http.Cookie, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestCSRF_DoubleSubmitCookie(t *testing.T) {
+	engine := newCSRFTestEngine(CSRFConfig{ExemptPaths: []string{"/webhook/*"}})
+
+	form := serveWithCookies(engine, "/form", nil)
+	token := form.Body.String()
+	cookies := form.Result().Cookies()
+	if token == "" || len(cookies) != 1 || cookies[0].HttpOnly {
+		t.Fatalf("Expected token and script readable cookie, got %q %v", token, cookies)
+	}
+
+	// A cross-site post without the token is rejected
+	if w := postForm(engine, "/login", url.Values{"user": {"tom"}}, cookies, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+	// The masked token from the form field is accepted
+	if w := postForm(engine, "/login", url.Values{"_csrf": {token}}, cookies, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// AJAX clients may echo the cookie value in the header
+	header := map[string]string{"X-CSRF-Token": cookies[0].Value}
+	if w := postForm(engine, "/login", nil, cookies, header); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// Exempt paths skip the check
+	if w := postForm(engine, "/webhook/github", nil, nil, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestCSRF_SynchronizerToken(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+	engine := newCSRFTestEngine(CSRFConfig{UseSession: true}, Sessions("session", store))
+
+	form := serveWithCookies(engine, "/form", nil)
+	token := form.Body.String()
+	cookies := form.Result().Cookies()
+
+	if w := postForm(engine, "/login", url.Values{"_csrf": {token}}, cookies, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// A token from another session is rejected
+	other := serveWithCookies(engine, "/form", nil).Body.String()
+	if w := postForm(engine, "/login", url.Values{"_csrf": {other}}, cookies, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestCSRF_SessionWithoutSessions(t *testing.T) {
+	engine := newCSRFTestEngine(CSRFConfig{UseSession: true})
+	defer func() {
+		if err := recover(); err != "tsweb: CSRF UseSession requires the Sessions middleware" {
+			t.Errorf("Expected panic for UseSession without Sessions, got %v", err)
+		}
+	}()
+	serveWithCookies(engine, "/form", nil)
+}
+
+func TestCSRF_MaskedTokensDiffer(t *testing.T) {
+	token := []byte("0123456789abcdef0123456789abcdef")
+	first, second := maskCSRFToken(token), maskCSRFToken(token)
+	if first == second {
+		t.Error("Expected a fresh mask for every token")
+	}
+	if !verifyCSRFToken(token, first) || !verifyCSRFToken(token, second) {
+		t.Error("Expected masked tokens to verify")
+	}
+}
+
+func TestCSRFFuncMap(t *testing.T) {
+	field := CSRFFuncMap(CSRFConfig{})["csrfField"].(func(string) string)
+	if html := field(`a"b`); html != `<input type="hidden" name="_csrf" value="a&#34;b">` {
+		t.Errorf("Unexpected hidden field: %s", html)
+	}
+}
@@ -0,0 +1,63 @@
+package tsweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"unicode/utf16"
+)
+
+// JSONCodec defines the JSON encoder and decoder used by an Engine.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)                              // Marshal encodes v as JSON
+	MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) // MarshalIndent encodes v as indented JSON
+	Unmarshal(data []byte, v interface{}) error                         // Unmarshal decodes data into v
+}
+
+// stdJSONCodec is the default JSONCodec backed by encoding/json.
+type stdJSONCodec struct{}
+
+// Marshal encodes v using encoding/json.
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MarshalIndent encodes v using encoding/json with indentation.
+func (stdJSONCodec) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return json.MarshalIndent(v, prefix, indent)
+}
+
+// Unmarshal decodes data using encoding/json.
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// defaultSecureJSONPrefix is prepended to SecureJSON responses unless the Engine overrides it.
+const defaultSecureJSONPrefix = "while(1);"
+
+// jsonpCallbackRegexp matches JSONP callback names such as "cb" or "app.handlers.cb".
+var jsonpCallbackRegexp = regexp.MustCompile(`^[a-zA-Z_$][0-9a-zA-Z_$]*(\.[a-zA-Z_$][0-9a-zA-Z_$]*)*$`)
+
+// isValidJSONPCallback reports whether name is safe to use as a JSONP callback.
+func isValidJSONPCallback(name string) bool {
+	return len(name) <= 128 && jsonpCallbackRegexp.MatchString(name)
+}
+
+// toASCIIJSON escapes every non-ASCII rune in data as a \uXXXX sequence.
+func toASCIIJSON(data []byte) []byte {
+	var buffer bytes.Buffer
+	for _, r := range string(data) {
+		if r < 0x80 {
+			buffer.WriteRune(r)
+			continue
+		}
+		if r > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buffer, "\\u%04x\\u%04x", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&buffer, "\\u%04x", r)
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,111 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContext_JSONStruct(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(w, req, NewEngine())
+
+	// Test JSON method with a slice of structs
+	c.JSON(http.StatusCreated, []struct{ Name string }{{"tom"}, {"jerry"}})
+	if w.Code != http.StatusCreated || w.Body.String() != `[{"Name":"tom"},{"Name":"jerry"}]` {
+		t.Errorf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestContext_JSONMarshalError(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(w, req, NewEngine())
+
+	// Channels cannot be marshalled, the response must be a clean 500
+	c.JSON(http.StatusOK, H{"ch": make(chan int)})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct == "application/json" {
+		t.Errorf("Expected no JSON content type on error, got %s", ct)
+	}
+}
+
+func TestContext_SecureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(w, req, NewEngine())
+
+	c.SecureJSON(http.StatusOK, []int{1, 2})
+	if w.Body.String() != "while(1);[1,2]" {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestContext_JSONP(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		status int
+		body   string
+	}{
+		{"Callback", "/test?callback=app.cb", http.StatusOK, `/**/app.cb({"a":1});`},
+		{"NoCallback", "/test", http.StatusOK, `{"a":1}`},
+		{"InvalidCallback", "/test?callback=alert(1)", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			c := makeContext(w, req, NewEngine())
+
+			c.JSONP(http.StatusOK, H{"a": 1})
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestContext_AsciiJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(w, req, NewEngine())
+
+	c.AsciiJSON(http.StatusOK, H{"lang": "GO语言", "emoji": "😀"})
+	expected := `{"emoji":"\ud83d\ude00","lang":"GO\u8bed\u8a00"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+// upperCodec is a JSONCodec that wraps the default codec and records its usage.
+type upperCodec struct {
+	stdJSONCodec
+	called bool
+}
+
+// Marshal records the call and delegates to the default codec.
+func (u *upperCodec) Marshal(v interface{}) ([]byte, error) {
+	u.called = true
+	return u.stdJSONCodec.Marshal(v)
+}
+
+func TestEngine_SetJSONCodec(t *testing.T) {
+	engine := NewEngine()
+	codec := &upperCodec{}
+	engine.SetJSONCodec(codec)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	makeContext(w, req, engine).JSON(http.StatusOK, H{"a": 1})
+	if !codec.called {
+		t.Error("Expected custom codec to be used")
+	}
+}
@@ -0,0 +1,444 @@
+package tsweb
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWT verification errors.
+var (
+	ErrJWTMalformed  = errors.New("tsweb: malformed token")             // ErrJWTMalformed reports a token that cannot be decoded
+	ErrJWTAlgorithm  = errors.New("tsweb: token algorithm not allowed") // ErrJWTAlgorithm reports an unexpected alg header
+	ErrJWTSignature  = errors.New("tsweb: invalid token signature")     // ErrJWTSignature reports a signature mismatch
+	ErrJWTUnknownKey = errors.New("tsweb: unknown token key")           // ErrJWTUnknownKey reports a kid without a matching key
+	ErrJWTExpired    = errors.New("tsweb: token expired")               // ErrJWTExpired reports an exp claim in the past
+	ErrJWTNotYet     = errors.New("tsweb: token not valid yet")         // ErrJWTNotYet reports an nbf claim in the future
+	ErrJWTIssuer     = errors.New("tsweb: invalid token issuer")        // ErrJWTIssuer reports an unexpected iss claim
+	ErrJWTAudience   = errors.New("tsweb: invalid token audience")      // ErrJWTAudience reports a missing aud entry
+)
+
+// jwksRefreshInterval limits how often a JWKS is reloaded because of an unknown kid.
+const jwksRefreshInterval = time.Minute
+
+// JWTClaims holds the decoded claims of a verified token.
+type JWTClaims map[string]interface{}
+
+// Subject returns the sub claim.
+func (c JWTClaims) Subject() string {
+	subject, _ := c["sub"].(string)
+	return subject
+}
+
+// Issuer returns the iss claim.
+func (c JWTClaims) Issuer() string {
+	issuer, _ := c["iss"].(string)
+	return issuer
+}
+
+// Audience returns the aud claim, which may be a string or an array of strings.
+func (c JWTClaims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		audience := make([]string, 0, len(aud))
+		for _, item := range aud {
+			if value, ok := item.(string); ok {
+				audience = append(audience, value)
+			}
+		}
+		return audience
+	}
+	return nil
+}
+
+// numericDate returns the named NumericDate claim as a time, failing when it is not a number.
+func (c JWTClaims) numericDate(name string) (time.Time, bool, error) {
+	raw, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		return time.Time{}, false, ErrJWTMalformed
+	}
+	return time.Unix(int64(value), 0), true, nil
+}
+
+// JWTConfig configures JWT verification.
+type JWTConfig struct {
+	HMACKey   []byte           // Secret for HS256 tokens
+	PublicKey crypto.PublicKey // RSA or ECDSA key used when KeySet has no key for the kid
+	KeySet    *JWKS            // Key set for RS256 and ES256 tokens, looked up by kid
+	Issuer    string           // Required iss claim, unchecked when empty
+	Audience  string           // Required aud entry, unchecked when empty
+	ClockSkew time.Duration    // Tolerance applied to exp and nbf
+	Now       func() time.Time // Clock used for validation, defaults to time.Now
+}
+
+// jwtHeader is the decoded JOSE header of a token.
+type jwtHeader struct {
+	Alg string `json:"alg"` // Signing algorithm
+	Kid string `json:"kid"` // Key identifier
+}
+
+// JWT is a middleware requiring a valid "Authorization: Bearer" JWT. The verified claims
+// are available through Context.Claims and the sub claim becomes the principal.
+func JWT(config JWTConfig) HandlerFunc {
+	return func(c *Context) {
+		token, ok := bearerToken(c.Req)
+		if !ok {
+			unauthorized(c, "Bearer")
+			return
+		}
+		claims, err := VerifyJWT(token, config)
+		if err != nil {
+			unauthorized(c, `Bearer error="invalid_token", error_description="`+strings.TrimPrefix(err.Error(), "tsweb: ")+`"`)
+			return
+		}
+		c.jwtClaims = claims
+		c.SetPrincipal(claims.Subject())
+		c.Next()
+	}
+}
+
+// Claims returns the claims verified by the JWT middleware, or nil if there are none.
+func (p *Context) Claims() JWTClaims {
+	return p.jwtClaims
+}
+
+// VerifyJWT verifies the signature and registered claims of a compact serialized token.
+func VerifyJWT(token string, config JWTConfig) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	if err := config.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims JWTClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := config.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// decodeJWTPart decodes a base64url encoded JSON token part into v.
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrJWTMalformed
+	}
+	return nil
+}
+
+// verifySignature checks the signature with the key configured for the header's
+// algorithm. Only algorithms with a configured key are accepted.
+func (config JWTConfig) verifySignature(header jwtHeader, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch header.Alg {
+	case "HS256":
+		if len(config.HMACKey) == 0 {
+			return ErrJWTAlgorithm
+		}
+		mac := hmac.New(sha256.New, config.HMACKey)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrJWTSignature
+		}
+		return nil
+	case "RS256":
+		key, err := config.publicKey(header.Kid)
+		if err != nil {
+			return err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrJWTAlgorithm
+		}
+		if rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature) != nil {
+			return ErrJWTSignature
+		}
+		return nil
+	case "ES256":
+		key, err := config.publicKey(header.Kid)
+		if err != nil {
+			return err
+		}
+		ecdsaKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecdsaKey.Curve != elliptic.P256() {
+			return ErrJWTAlgorithm
+		}
+		if len(signature) != 64 {
+			return ErrJWTSignature
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecdsaKey, digest[:], r, s) {
+			return ErrJWTSignature
+		}
+		return nil
+	}
+	return ErrJWTAlgorithm
+}
+
+// publicKey returns the key for kid from the key set, falling back to PublicKey.
+func (config JWTConfig) publicKey(kid string) (crypto.PublicKey, error) {
+	if config.KeySet != nil {
+		if key, ok := config.KeySet.Key(kid); ok {
+			return key, nil
+		}
+	}
+	if config.PublicKey != nil {
+		return config.PublicKey, nil
+	}
+	return nil, ErrJWTUnknownKey
+}
+
+// validateClaims checks exp, nbf, iss and aud.
+func (config JWTConfig) validateClaims(claims JWTClaims) error {
+	now := time.Now()
+	if config.Now != nil {
+		now = config.Now()
+	}
+	exp, ok, err := claims.numericDate("exp")
+	if err != nil {
+		return err
+	}
+	if ok && now.After(exp.Add(config.ClockSkew)) {
+		return ErrJWTExpired
+	}
+	nbf, ok, err := claims.numericDate("nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(config.ClockSkew).Before(nbf) {
+		return ErrJWTNotYet
+	}
+	if config.Issuer != "" && claims.Issuer() != config.Issuer {
+		return ErrJWTIssuer
+	}
+	if config.Audience != "" {
+		for _, audience := range claims.Audience() {
+			if audience == config.Audience {
+				return nil
+			}
+		}
+		return ErrJWTAudience
+	}
+	return nil
+}
+
+// JWKS is a JSON Web Key Set holding RSA and P-256 public keys by kid. Key sets created
+// with a source reload it, at most once a minute, when an unknown kid is requested.
+type JWKS struct {
+	mutex      sync.RWMutex                // Guards keys and lastLoaded
+	keys       map[string]crypto.PublicKey // Public keys by kid
+	source     func() ([]byte, error)      // Optional loader used to refresh the set
+	lastLoaded time.Time                   // Time of the last load from source
+}
+
+// jsonWebKey is the JSON representation of a single key.
+type jsonWebKey struct {
+	Kty string `json:"kty"` // Key type, "RSA" or "EC"
+	Kid string `json:"kid"` // Key identifier
+	Use string `json:"use"` // Intended use, only "sig" keys are loaded
+	Crv string `json:"crv"` // Curve of an EC key
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA public exponent
+	X   string `json:"x"`   // EC x coordinate
+	Y   string `json:"y"`   // EC y coordinate
+}
+
+// ParseJWKS parses a JSON Web Key Set document.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	keys, err := parseJWKSKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return &JWKS{keys: keys}, nil
+}
+
+// LoadJWKSFile loads a JSON Web Key Set from a local file, which is re-read when a
+// token references an unknown kid.
+func LoadJWKSFile(path string) (*JWKS, error) {
+	return newJWKSFromSource(func() ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// LoadJWKSHandler loads a JSON Web Key Set by issuing an in-process GET request for
+// path to handler, such as another Engine publishing its keys.
+func LoadJWKSHandler(handler http.Handler, path string) (*JWKS, error) {
+	return newJWKSFromSource(func() ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		w := &bufferResponseWriter{header: make(http.Header), status: http.StatusOK}
+		handler.ServeHTTP(w, req)
+		if w.status != http.StatusOK {
+			return nil, fmt.Errorf("tsweb: JWKS handler returned status %d", w.status)
+		}
+		return w.body.Bytes(), nil
+	})
+}
+
+// newJWKSFromSource creates a key set and performs the initial load from source.
+func newJWKSFromSource(source func() ([]byte, error)) (*JWKS, error) {
+	jwks := &JWKS{source: source}
+	if err := jwks.Reload(); err != nil {
+		return nil, err
+	}
+	return jwks, nil
+}
+
+// Reload reloads the key set from its source. It is a no-op for parsed key sets.
+func (j *JWKS) Reload() error {
+	if j.source == nil {
+		return nil
+	}
+	data, err := j.source()
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKSKeys(data)
+	if err != nil {
+		return err
+	}
+	j.mutex.Lock()
+	j.keys = keys
+	j.lastLoaded = time.Now()
+	j.mutex.Unlock()
+	return nil
+}
+
+// Key returns the key for kid. An empty kid matches when the set holds a single key.
+func (j *JWKS) Key(kid string) (crypto.PublicKey, bool) {
+	if key, ok := j.lookup(kid); ok {
+		return key, true
+	}
+	j.mutex.RLock()
+	stale := j.source != nil && time.Since(j.lastLoaded) > jwksRefreshInterval
+	j.mutex.RUnlock()
+	if stale && j.Reload() == nil {
+		return j.lookup(kid)
+	}
+	return nil, false
+}
+
+// lookup returns the key for kid without reloading.
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// parseJWKSKeys decodes the signature keys of a JSON Web Key Set.
+func parseJWKSKeys(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("tsweb: invalid JWK %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// publicKey converts the JWK into an *rsa.PublicKey or *ecdsa.PublicKey.
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, ErrJWTMalformed
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, ErrJWTMalformed
+		}
+		// Validate that the point is on the curve before using it
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+// bufferResponseWriter is an http.ResponseWriter that records the response in memory.
+type bufferResponseWriter struct {
+	header http.Header  // Response headers
+	status int          // Response status code
+	body   bytes.Buffer // Response body
+}
+
+// Header returns the response headers.
+func (w *bufferResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status code.
+func (w *bufferResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// Write appends data to the body.
+func (w *bufferResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
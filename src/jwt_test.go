@@ -0,0 +1,184 @@
+package tsweb
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// signTestJWT creates a compact token signed with key using alg.
+func signTestJWT(t *testing.T, alg string, kid string, key interface{}, claims JWTClaims) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case "RS256":
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "none":
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// testJWKS builds a JWKS document for an RSA and an ECDSA key.
+func testJWKS(rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	document, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}})
+	return document
+}
+
+func TestVerifyJWT(t *testing.T) {
+	secret := []byte("hmac-secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keySet, err := ParseJWKS(testJWKS(rsaKey, ecKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	config := JWTConfig{
+		HMACKey:   secret,
+		KeySet:    keySet,
+		Issuer:    "https://auth.example.com",
+		Audience:  "api",
+		ClockSkew: 30 * time.Second,
+		Now:       func() time.Time { return now },
+	}
+	valid := JWTClaims{"sub": "tom", "iss": "https://auth.example.com", "aud": []string{"web", "api"}, "exp": now.Unix() + 60}
+	with := func(key string, value interface{}) JWTClaims {
+		claims := JWTClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"HS256", signTestJWT(t, "HS256", "", secret, valid), nil},
+		{"RS256", signTestJWT(t, "RS256", "rsa-1", rsaKey, valid), nil},
+		{"ES256", signTestJWT(t, "ES256", "ec-1", ecKey, valid), nil},
+		{"WrongSecret", signTestJWT(t, "HS256", "", []byte("other"), valid), ErrJWTSignature},
+		{"AlgNone", signTestJWT(t, "none", "", nil, valid), ErrJWTAlgorithm},
+		{"UnknownKid", signTestJWT(t, "RS256", "rsa-2", rsaKey, valid), ErrJWTUnknownKey},
+		{"KeyTypeConfusion", signTestJWT(t, "ES256", "rsa-1", ecKey, valid), ErrJWTAlgorithm},
+		{"ExpiredWithinSkew", signTestJWT(t, "HS256", "", secret, with("exp", now.Unix()-20)), nil},
+		{"Expired", signTestJWT(t, "HS256", "", secret, with("exp", now.Unix()-60)), ErrJWTExpired},
+		{"NotYetValid", signTestJWT(t, "HS256", "", secret, with("nbf", now.Unix()+60)), ErrJWTNotYet},
+		{"StringExpiry", signTestJWT(t, "HS256", "", secret, with("exp", "1")), ErrJWTMalformed},
+		{"NullNotBefore", signTestJWT(t, "HS256", "", secret, with("nbf", nil)), ErrJWTMalformed},
+		{"WrongIssuer", signTestJWT(t, "HS256", "", secret, with("iss", "https://evil.example.com")), ErrJWTIssuer},
+		{"WrongAudience", signTestJWT(t, "HS256", "", secret, with("aud", "web")), ErrJWTAudience},
+		{"Malformed", "not.a-token", ErrJWTMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := VerifyJWT(tt.token, config)
+			if err != tt.err {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if err == nil && claims.Subject() != "tom" {
+				t.Errorf("Expected subject 'tom', got '%s'", claims.Subject())
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	secret := []byte("hmac-secret")
+	engine := NewEngine()
+	api := engine.Group("/api")
+	api.Use(JWT(JWTConfig{HMACKey: secret}))
+	api.GET("/me", func(c *Context) {
+		c.String(http.StatusOK, "%s %v", c.Principal(), c.Claims()["role"])
+	})
+
+	token := signTestJWT(t, "HS256", "", secret, JWTClaims{"sub": "tom", "role": "admin"})
+	req, _ := http.NewRequest("GET", "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "tom admin" {
+		t.Errorf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token+"x")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestJWKSSources(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	document := testJWKS(rsaKey, ecKey)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, document, 0600); err != nil {
+		t.Fatal(err)
+	}
+	fileSet, err := LoadJWKSFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := NewEngine()
+	issuer.GET("/.well-known/jwks.json", func(c *Context) {
+		c.Data(http.StatusOK, document)
+	})
+	handlerSet, err := LoadJWKSHandler(issuer, "/.well-known/jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, keySet := range map[string]*JWKS{"File": fileSet, "Handler": handlerSet} {
+		if _, ok := keySet.Key("ec-1"); !ok {
+			t.Errorf("%s: expected key ec-1", name)
+		}
+		if _, ok := keySet.Key("rsa-1"); !ok {
+			t.Errorf("%s: expected key rsa-1", name)
+		}
+	}
+
+	if _, err := LoadJWKSHandler(issuer, "/missing"); err == nil {
+		t.Error("Expected error for failing JWKS handler")
+	}
+}
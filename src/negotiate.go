@@ -0,0 +1,77 @@
+package tsweb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a single media range from an Accept header.
+type acceptRange struct {
+	mediaType string  // Media type such as "text/*"
+	quality   float64 // Value of the q parameter, defaults to 1
+}
+
+// parseAccept parses an Accept header into its media ranges.
+func parseAccept(header string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, item := range strings.Split(header, ",") {
+		fields := strings.Split(item, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality the ranges assign to offer, using the most specific
+// matching range. It returns -1 when no range matches.
+func acceptQuality(ranges []acceptRange, offer string) float64 {
+	offer = strings.ToLower(strings.TrimSpace(strings.Split(offer, ";")[0]))
+	offerType, _, _ := strings.Cut(offer, "/")
+	quality, specificity := -1.0, -1
+	for _, r := range ranges {
+		current := -1
+		switch {
+		case r.mediaType == offer:
+			current = 2
+		case r.mediaType == offerType+"/*":
+			current = 1
+		case r.mediaType == "*/*" || r.mediaType == "*":
+			current = 0
+		}
+		if current > specificity {
+			quality, specificity = r.quality, current
+		}
+	}
+	return quality
+}
+
+// negotiateFormat returns the index of the offer preferred by the Accept header, or -1
+// if none is acceptable. An empty header accepts the first offer; ties keep offer order.
+func negotiateFormat(header string, offers []string) int {
+	if len(offers) == 0 {
+		return -1
+	}
+	if strings.TrimSpace(header) == "" {
+		return 0
+	}
+	ranges := parseAccept(header)
+	best, bestQuality := -1, 0.0
+	for index, offer := range offers {
+		if quality := acceptQuality(ranges, offer); quality > bestQuality {
+			best, bestQuality = index, quality
+		}
+	}
+	return best
+}
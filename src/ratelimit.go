@@ -0,0 +1,206 @@
+package tsweb
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitResult is the outcome of taking a request from a rate limit store.
+type RateLimitResult struct {
+	Allowed    bool          // Whether the request is within the limit
+	Limit      int           // Maximum number of requests per window
+	Remaining  int           // Requests left before the limit is reached
+	Reset      time.Duration // Time until the allowance is fully restored
+	RetryAfter time.Duration // Time until the next request is allowed, when denied
+}
+
+// RateLimitStore tracks request allowances per key. The in-memory store is used by
+// default; shared backends such as Redis can implement the same interface.
+type RateLimitStore interface {
+	Take(key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimitConfig configures the RateLimit middleware.
+type RateLimitConfig struct {
+	Limit        int                   // Requests allowed per window
+	Window       time.Duration         // Window over which Limit requests are allowed
+	KeyFunc      func(*Context) string // Key extractor, defaults to KeyByIP; an empty key skips the limit
+	Store        RateLimitStore        // Store tracking allowances, defaults to a new in-memory store
+	ErrorHandler HandlerFunc           // Called for limited requests, defaults to a 429 response
+}
+
+// RateLimit is a middleware limiting requests per key with a token bucket. It sets the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers and Retry-After on 429.
+// It panics unless Limit and Window are positive.
+func RateLimit(config RateLimitConfig) HandlerFunc {
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic(fmt.Sprintf("tsweb: rate limit needs a positive Limit and Window, got %d per %v", config.Limit, config.Window))
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore(config.Window)
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *Context) {
+			c.Error(http.StatusTooManyRequests, "Too Many Requests")
+		}
+	}
+	policy := strconv.Itoa(config.Limit) + ";w=" + strconv.Itoa(int(config.Window/time.Second))
+
+	return func(c *Context) {
+		key := config.KeyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		result, err := config.Store.Take(key, config.Limit, config.Window)
+		if err != nil {
+			// Fail open so an unavailable store does not take the service down
+			log.Printf("%sRate limit store error: %v", requestIDPrefix(c), err)
+			c.Next()
+			return
+		}
+
+		c.SetHeader("RateLimit-Policy", policy)
+		c.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.SetHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.SetHeader("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			config.ErrorHandler(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// KeyByIP returns the client IP address of the request as the rate limit key.
+func KeyByIP(c *Context) string {
+	return c.ClientIP()
+}
+
+// KeyByHeader returns a key extractor using the value of header, such as an API key.
+func KeyByHeader(header string) func(*Context) string {
+	return func(c *Context) string {
+		if value := c.Req.Header.Get(header); value != "" {
+			return header + ":" + value
+		}
+		return ""
+	}
+}
+
+// tokenBucket is the state of a single key in a MemoryRateLimitStore.
+type tokenBucket struct {
+	tokens   float64       // Available tokens
+	updated  time.Time     // Time tokens was last refilled
+	capacity float64       // Bucket capacity, the limit
+	window   time.Duration // Time needed to refill an empty bucket
+}
+
+// MemoryRateLimitStore is an in-memory token bucket RateLimitStore. Idle buckets are
+// removed periodically until Close is called.
+type MemoryRateLimitStore struct {
+	mutex   sync.Mutex              // Guards buckets
+	buckets map[string]*tokenBucket // Buckets by key
+	now     func() time.Time        // Clock, replaceable in tests
+	done    chan struct{}           // Closed to stop the cleanup goroutine
+}
+
+// NewMemoryRateLimitStore creates an in-memory store that evicts idle buckets every
+// cleanupInterval.
+func NewMemoryRateLimitStore(cleanupInterval time.Duration) *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+	go s.cleanup(cleanupInterval)
+	return s
+}
+
+// Take consumes a token from the bucket of key, refilling it at limit tokens per window.
+func (s *MemoryRateLimitStore) Take(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit), updated: now}
+		s.buckets[key] = bucket
+	}
+	bucket.capacity, bucket.window = float64(limit), window
+	rate := float64(limit) / window.Seconds()
+	bucket.tokens = math.Min(bucket.capacity, bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+
+	result := RateLimitResult{Limit: limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - bucket.tokens) / rate)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = secondsDuration((bucket.capacity - bucket.tokens) / rate)
+	return result, nil
+}
+
+// secondsDuration converts fractional seconds into a Duration.
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// cleanup removes idle buckets every interval until the store is closed.
+func (s *MemoryRateLimitStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deleteIdle()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// deleteIdle removes buckets that have refilled completely, since they are
+// indistinguishable from new ones.
+func (s *MemoryRateLimitStore) deleteIdle() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= bucket.window {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of tracked keys.
+func (s *MemoryRateLimitStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.buckets)
+}
+
+// Close stops the cleanup goroutine.
+func (s *MemoryRateLimitStore) Close() {
+	close(s.done)
+}
@@ -0,0 +1,147 @@
+package tsweb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newRateLimitTestEngine creates an engine with an API group limited by config.
+func newRateLimitTestEngine(config RateLimitConfig) *Engine {
+	engine := NewEngine()
+	engine.GET("/free", func(c *Context) {
+		c.String(http.StatusOK, "free")
+	})
+	api := engine.Group("/api")
+	api.Use(RateLimit(config))
+	api.GET("/data", func(c *Context) {
+		c.String(http.StatusOK, "data")
+	})
+	return engine
+}
+
+// serveRateLimited sends a GET request from remoteAddr with optional API key.
+func serveRateLimited(engine *Engine, path string, remoteAddr string, apiKey string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.RemoteAddr = remoteAddr
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_PerIP(t *testing.T) {
+	store := NewMemoryRateLimitStore(time.Minute)
+	defer store.Close()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+	engine := newRateLimitTestEngine(RateLimitConfig{Limit: 2, Window: time.Minute, Store: store})
+
+	for i := 0; i < 2; i++ {
+		if w := serveRateLimited(engine, "/api/data", "10.0.0.1:1234", ""); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status code %d, got %d", i, http.StatusOK, w.Code)
+		}
+	}
+	w := serveRateLimited(engine, "/api/data", "10.0.0.1:5678", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	expected := map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+		"RateLimit-Policy":    "2;w=60",
+		"Retry-After":         "30",
+	}
+	for key, value := range expected {
+		if w.Header().Get(key) != value {
+			t.Errorf("Expected %s %s, got %s", key, value, w.Header().Get(key))
+		}
+	}
+
+	// Other clients and ungrouped routes are unaffected
+	if w := serveRateLimited(engine, "/api/data", "10.0.0.2:1234", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := serveRateLimited(engine, "/free", "10.0.0.1:1234", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Tokens are refilled over time
+	now = now.Add(30 * time.Second)
+	if w := serveRateLimited(engine, "/api/data", "10.0.0.1:1234", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d after refill, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimit_PerAPIKey(t *testing.T) {
+	engine := newRateLimitTestEngine(RateLimitConfig{Limit: 1, Window: time.Minute, KeyFunc: KeyByHeader("X-API-Key")})
+
+	if w := serveRateLimited(engine, "/api/data", "10.0.0.1:1", "key-a"); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := serveRateLimited(engine, "/api/data", "10.0.0.2:1", "key-a"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w := serveRateLimited(engine, "/api/data", "10.0.0.1:1", "key-b"); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// failingStore is a RateLimitStore that always fails.
+type failingStore struct{}
+
+// Take always returns an error.
+func (failingStore) Take(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store unavailable")
+}
+
+func TestRateLimit_StoreErrorFailsOpen(t *testing.T) {
+	engine := newRateLimitTestEngine(RateLimitConfig{Limit: 1, Window: time.Minute, Store: failingStore{}})
+	if w := serveRateLimited(engine, "/api/data", "10.0.0.1:1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestMemoryRateLimitStore_Cleanup(t *testing.T) {
+	store := NewMemoryRateLimitStore(time.Hour)
+	defer store.Close()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	store.Take("a", 10, time.Minute)
+	store.deleteIdle()
+	if store.Len() != 1 {
+		t.Errorf("Expected active bucket to be kept, got %d buckets", store.Len())
+	}
+	now = now.Add(time.Minute)
+	store.deleteIdle()
+	if store.Len() != 0 {
+		t.Errorf("Expected idle bucket to be removed, got %d buckets", store.Len())
+	}
+}
+
+func TestRateLimit_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RateLimitConfig
+	}{
+		{"MissingWindow", RateLimitConfig{Limit: 10}},
+		{"ZeroLimit", RateLimitConfig{Window: time.Minute}},
+		{"NegativeWindow", RateLimitConfig{Limit: 10, Window: -time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected panic for invalid rate limit config")
+				}
+			}()
+			RateLimit(tt.config)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// SetUseRawPath makes the router match routes against the escaped request path, so
+// an encoded slash (%2F) stays inside a parameter instead of splitting segments.
+func (p *Engine) SetUseRawPath(enabled bool) {
+	p.useRawPath = enabled
+}
+
+// SetUnescapePathValues controls whether parameters matched against the escaped path
+// are unescaped. It is enabled by default and only has an effect with UseRawPath.
+func (p *Engine) SetUnescapePathValues(enabled bool) {
+	p.unescapePathValues = enabled
+}
+
+// routePath returns the path routes are matched against: the escaped path with
+// UseRawPath, the decoded path otherwise.
+func (p *Engine) routePath(req *http.Request) string {
+	if p.useRawPath {
+		return req.URL.EscapedPath()
+	}
+	return req.URL.Path
+}
+
+// pathValues unescapes params matched against the escaped path if UnescapePathValues
+// is enabled. Values that are not valid escapes are kept as they are.
+func (p *Engine) pathValues(params map[string]string) map[string]string {
+	if !p.useRawPath || !p.unescapePathValues {
+		return params
+	}
+	for name, value := range params {
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			params[name] = unescaped
+		}
+	}
+	return params
+}
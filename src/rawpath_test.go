@@ -0,0 +1,65 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEngine_UseRawPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawPath  bool
+		unescape bool
+		path     string
+		status   int
+		body     string
+	}{
+		{"DecodedPathSplitsSlash", false, true, "/objects/a%2Fb", http.StatusNotFound, "404"},
+		{"DecodedPath", false, true, "/objects/a%20b", http.StatusOK, "key=a b"},
+		{"RawPathUnescaped", true, true, "/objects/a%2Fb", http.StatusOK, "key=a/b"},
+		{"RawPathEscaped", true, false, "/objects/a%2Fb", http.StatusOK, "key=a%2Fb"},
+		{"RawPathWildcard", true, true, "/files/dir/a%2Fb", http.StatusOK, "path=dir/a/b"},
+		{"RawPathPlain", true, true, "/objects/plain", http.StatusOK, "key=plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine()
+			engine.SetUseRawPath(tt.rawPath)
+			engine.SetUnescapePathValues(tt.unescape)
+			engine.GET("/objects/:key", func(c *Context) {
+				c.String(http.StatusOK, "key=%s", c.Param("key"))
+			})
+			engine.GET("/files/*path", func(c *Context) {
+				c.String(http.StatusOK, "path=%s", c.Param("path"))
+			})
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("Expected body '%s', got '%s'", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestEngine_UseRawPathRedirect(t *testing.T) {
+	engine := NewEngine()
+	engine.SetUseRawPath(true)
+	engine.SetRedirectTrailingSlash(true)
+	engine.GET("/objects/:key", func(c *Context) {})
+
+	req, _ := http.NewRequest("GET", "/objects/a%2Fb/", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected status code %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/objects/a%2Fb" {
+		t.Errorf("Expected Location '/objects/a%%2Fb', got '%s'", location)
+	}
+}
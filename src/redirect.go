@@ -0,0 +1,123 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// SetRedirectTrailingSlash enables redirects from /foo/ to /foo, or from /foo to /foo/,
+// when only the other form has a route. Without it both forms reach the route.
+func (p *Engine) SetRedirectTrailingSlash(enabled bool) {
+	p.redirectTrailingSlash = enabled
+}
+
+// SetRedirectFixedPath enables redirects to the cleaned path, without ./ and ../
+// elements or repeated slashes, and to routes matching case-insensitively.
+func (p *Engine) SetRedirectFixedPath(enabled bool) {
+	p.redirectFixedPath = enabled
+}
+
+// SetRemoveExtraSlash enables redirects from paths with repeated slashes such as
+// /hello//world to the path with single slashes.
+func (p *Engine) SetRemoveExtraSlash(enabled bool) {
+	p.removeExtraSlash = enabled
+}
+
+// redirectFixed redirects the request to its canonical path if the path options are
+// enabled and the canonical path, but not the requested form, matches a route. The
+// routes of a host router matching the request host are considered first.
+// It reports whether a redirect was sent.
+func (p *Engine) redirectFixed(c *Context) bool {
+	if !p.redirectTrailingSlash && !p.redirectFixedPath && !p.removeExtraSlash {
+		return false
+	}
+	routers := []*Router{p.router}
+	if hostRouter, _ := p.router.matchHost(c.Host()); hostRouter != nil {
+		routers = []*Router{hostRouter, p.router}
+	}
+	requestPath := p.routePath(c.Req)
+	fixed := requestPath
+	for _, router := range routers {
+		candidate := p.canonicalPath(router, c.Method, requestPath)
+		if n, _ := router.getRoute(c.Method, candidate); n != nil {
+			fixed = candidate
+			break
+		}
+	}
+	if fixed == requestPath {
+		return false
+	}
+
+	location := fixed
+	if !p.useRawPath {
+		location = (&url.URL{Path: fixed}).EscapedPath()
+	}
+	// A location starting with // or /\ would be followed as a link to another host
+	location = "/" + strings.TrimLeft(location, "/\\")
+	if c.Req.URL.RawQuery != "" {
+		location += "?" + c.Req.URL.RawQuery
+	}
+	status := http.StatusPermanentRedirect
+	if c.Method == http.MethodGet || c.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+	c.SetHeader("Location", location)
+	c.Status(status)
+	return true
+}
+
+// canonicalPath applies the enabled path options to requestPath for the routes of router.
+func (p *Engine) canonicalPath(router *Router, method string, requestPath string) string {
+	fixed := requestPath
+	if p.removeExtraSlash {
+		fixed = removeRepeatedSlashes(fixed)
+	}
+	if p.redirectFixedPath {
+		fixed = cleanPath(fixed)
+		if n, _ := router.getRoute(method, fixed); n == nil {
+			if corrected, ok := router.findCaseInsensitive(method, fixed); ok {
+				fixed = corrected
+			}
+		}
+	}
+	if p.redirectTrailingSlash {
+		if n, _ := router.getRoute(method, fixed); n != nil {
+			fixed = matchTrailingSlash(fixed, n.pattern)
+		}
+	}
+	return fixed
+}
+
+// removeRepeatedSlashes replaces each run of slashes in p with a single slash.
+func removeRepeatedSlashes(p string) string {
+	for strings.Contains(p, "//") {
+		p = strings.ReplaceAll(p, "//", "/")
+	}
+	return p
+}
+
+// cleanPath returns the shortest equivalent of p, keeping a trailing slash.
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// matchTrailingSlash adds or removes the trailing slash of p to agree with pattern.
+// Paths matched by a *name segment are left alone.
+func matchTrailingSlash(p string, pattern string) string {
+	if p == "/" || strings.Contains(pattern, "/*") {
+		return p
+	}
+	if strings.HasSuffix(pattern, "/") {
+		if !strings.HasSuffix(p, "/") {
+			return p + "/"
+		}
+		return p
+	}
+	return strings.TrimSuffix(p, "/")
+}
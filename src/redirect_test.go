@@ -0,0 +1,112 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newRedirectTestEngine creates an engine with the given path options enabled.
+func newRedirectTestEngine(trailingSlash, fixedPath, extraSlash bool) *Engine {
+	engine := NewEngine()
+	engine.SetRedirectTrailingSlash(trailingSlash)
+	engine.SetRedirectFixedPath(fixedPath)
+	engine.SetRemoveExtraSlash(extraSlash)
+	handler := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	engine.GET("/hello", handler)
+	engine.GET("/docs/", handler)
+	engine.GET("/Users/:name/Profile", handler)
+	engine.POST("/submit", handler)
+	engine.GET("/assets/*filepath", handler)
+	return engine
+}
+
+func TestEngine_PathRedirects(t *testing.T) {
+	tests := []struct {
+		name          string
+		trailingSlash bool
+		fixedPath     bool
+		extraSlash    bool
+		method        string
+		path          string
+		status        int
+		location      string
+	}{
+		{"AliasWithoutOptions", false, false, false, "GET", "/hello/", http.StatusOK, ""},
+		{"RemoveTrailingSlash", true, false, false, "GET", "/hello/", http.StatusMovedPermanently, "/hello"},
+		{"AddTrailingSlash", true, false, false, "GET", "/docs", http.StatusMovedPermanently, "/docs/"},
+		{"KeepQuery", true, false, false, "GET", "/hello/?a=1", http.StatusMovedPermanently, "/hello?a=1"},
+		{"PostUses308", true, false, false, "POST", "/submit/", http.StatusPermanentRedirect, "/submit"},
+		{"WildcardUntouched", true, false, false, "GET", "/assets/css/", http.StatusOK, ""},
+		{"ExactMatch", true, true, true, "GET", "/hello", http.StatusOK, ""},
+		{"ExtraSlash", false, false, true, "GET", "/Users//tom///Profile", http.StatusMovedPermanently, "/Users/tom/Profile"},
+		{"ExtraSlashDisabled", false, false, false, "GET", "/Users//tom/Profile", http.StatusOK, ""},
+		{"DotSegments", false, true, false, "GET", "/docs/../hello", http.StatusMovedPermanently, "/hello"},
+		{"CaseInsensitive", false, true, false, "GET", "/users/Tom/profile", http.StatusMovedPermanently, "/Users/Tom/Profile"},
+		{"CaseAndSlash", true, true, false, "GET", "/HELLO/", http.StatusMovedPermanently, "/hello"},
+		{"FixedPathNotFound", true, true, true, "GET", "/missing/", http.StatusNotFound, ""},
+		{"CaseInsensitiveDisabled", true, false, true, "GET", "/HELLO", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRedirectTestEngine(tt.trailingSlash, tt.fixedPath, tt.extraSlash)
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("Expected Location '%s', got '%s'", tt.location, location)
+			}
+		})
+	}
+}
+
+func TestEngine_PathRedirectStaysOnHost(t *testing.T) {
+	engine := NewEngine()
+	engine.SetRedirectTrailingSlash(true)
+	engine.GET("/:page", func(c *Context) {})
+
+	tests := []struct {
+		path     string
+		location string
+	}{
+		{"//evil.com/", "/evil.com"},
+		{"///evil.com/", "/evil.com"},
+		{"/\\evil.com/", "/%5Cevil.com"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.URL.Path = tt.path
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusMovedPermanently {
+			t.Errorf("%s: expected status code %d, got %d", tt.path, http.StatusMovedPermanently, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != tt.location {
+			t.Errorf("%s: expected Location '%s', got '%s'", tt.path, tt.location, location)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/", "/"},
+		{"", "/"},
+		{"/a/./b/", "/a/b/"},
+		{"/a/../../b", "/b"},
+		{"//a//b", "/a/b"},
+		{"/a/b/..", "/a"},
+	}
+	for _, tt := range tests {
+		if cleaned := cleanPath(tt.path); cleaned != tt.expected {
+			t.Errorf("cleanPath(%q): expected '%s', got '%s'", tt.path, tt.expected, cleaned)
+		}
+	}
+}
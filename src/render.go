@@ -0,0 +1,235 @@
+package tsweb
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"text/template"
+)
+
+// Render encodes a response body. Encoding happens before any header is written,
+// so a failing renderer never produces a partially written response.
+type Render interface {
+	ContentType() string     // ContentType returns the Content-Type header value, or "" for none
+	Encode() ([]byte, error) // Encode returns the encoded response body
+}
+
+// StringRender renders a formatted plain text body.
+type StringRender struct {
+	Format string        // Format string passed to fmt.Sprintf
+	Data   []interface{} // Arguments for the format string
+}
+
+// ContentType returns the plain text content type.
+func (r StringRender) ContentType() string {
+	return "text/plain"
+}
+
+// Encode formats the string.
+func (r StringRender) Encode() ([]byte, error) {
+	return []byte(fmt.Sprintf(r.Format, r.Data...)), nil
+}
+
+// engineAware is implemented by renders that take defaults from the Engine.
+// Context.Render resolves them once before encoding.
+type engineAware interface {
+	withEngine(engine *Engine) Render // withEngine returns the render with unset fields filled in
+}
+
+// resolveCodec returns codec, else the engine codec, else the encoding/json codec.
+func resolveCodec(codec JSONCodec, engine *Engine) JSONCodec {
+	if codec != nil {
+		return codec
+	}
+	if engine != nil && engine.jsonCodec != nil {
+		return engine.jsonCodec
+	}
+	return stdJSONCodec{}
+}
+
+// JSONRender renders a value as JSON.
+type JSONRender struct {
+	Codec JSONCodec   // Codec used to encode Data, the engine codec when nil
+	Data  interface{} // Value to encode
+}
+
+// withEngine fills in the engine codec when Codec is nil.
+func (r JSONRender) withEngine(engine *Engine) Render {
+	r.Codec = resolveCodec(r.Codec, engine)
+	return r
+}
+
+// ContentType returns the JSON content type.
+func (r JSONRender) ContentType() string {
+	return "application/json"
+}
+
+// Encode marshals the value with the codec.
+func (r JSONRender) Encode() ([]byte, error) {
+	return resolveCodec(r.Codec, nil).Marshal(r.Data)
+}
+
+// IndentedJSONRender renders a value as pretty-printed JSON.
+type IndentedJSONRender struct {
+	Codec JSONCodec   // Codec used to encode Data, the engine codec when nil
+	Data  interface{} // Value to encode
+}
+
+// withEngine fills in the engine codec when Codec is nil.
+func (r IndentedJSONRender) withEngine(engine *Engine) Render {
+	r.Codec = resolveCodec(r.Codec, engine)
+	return r
+}
+
+// ContentType returns the JSON content type.
+func (r IndentedJSONRender) ContentType() string {
+	return "application/json"
+}
+
+// Encode marshals the value with four-space indentation.
+func (r IndentedJSONRender) Encode() ([]byte, error) {
+	return resolveCodec(r.Codec, nil).MarshalIndent(r.Data, "", "    ")
+}
+
+// SecureJSONRender renders a value as JSON preceded by an anti-hijacking prefix.
+type SecureJSONRender struct {
+	Codec  JSONCodec   // Codec used to encode Data, the engine codec when nil
+	Prefix *string     // Prefix written before the JSON body, the engine prefix when nil
+	Data   interface{} // Value to encode
+}
+
+// withEngine fills in the engine codec and prefix when they are nil.
+func (r SecureJSONRender) withEngine(engine *Engine) Render {
+	r.Codec = resolveCodec(r.Codec, engine)
+	if r.Prefix == nil && engine != nil {
+		prefix := engine.secureJSONPrefix
+		r.Prefix = &prefix
+	}
+	return r
+}
+
+// ContentType returns the JSON content type.
+func (r SecureJSONRender) ContentType() string {
+	return "application/json"
+}
+
+// Encode marshals the value and prepends the prefix.
+func (r SecureJSONRender) Encode() ([]byte, error) {
+	value, err := resolveCodec(r.Codec, nil).Marshal(r.Data)
+	if err != nil {
+		return nil, err
+	}
+	prefix := defaultSecureJSONPrefix
+	if r.Prefix != nil {
+		prefix = *r.Prefix
+	}
+	return append([]byte(prefix), value...), nil
+}
+
+// JSONPRender renders a value as JSON wrapped in a JavaScript callback.
+type JSONPRender struct {
+	Codec    JSONCodec   // Codec used to encode Data, the engine codec when nil
+	Callback string      // Callback name, must already be validated
+	Data     interface{} // Value to encode
+}
+
+// withEngine fills in the engine codec when Codec is nil.
+func (r JSONPRender) withEngine(engine *Engine) Render {
+	r.Codec = resolveCodec(r.Codec, engine)
+	return r
+}
+
+// ContentType returns the JavaScript content type.
+func (r JSONPRender) ContentType() string {
+	return "application/javascript"
+}
+
+// Encode marshals the value and wraps it in the callback invocation.
+func (r JSONPRender) Encode() ([]byte, error) {
+	value, err := resolveCodec(r.Codec, nil).Marshal(r.Data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("/**/%s(%s);", r.Callback, value)), nil
+}
+
+// AsciiJSONRender renders a value as JSON with non-ASCII characters escaped.
+type AsciiJSONRender struct {
+	Codec JSONCodec   // Codec used to encode Data, the engine codec when nil
+	Data  interface{} // Value to encode
+}
+
+// withEngine fills in the engine codec when Codec is nil.
+func (r AsciiJSONRender) withEngine(engine *Engine) Render {
+	r.Codec = resolveCodec(r.Codec, engine)
+	return r
+}
+
+// ContentType returns the JSON content type.
+func (r AsciiJSONRender) ContentType() string {
+	return "application/json"
+}
+
+// Encode marshals the value and escapes non-ASCII runes.
+func (r AsciiJSONRender) Encode() ([]byte, error) {
+	value, err := resolveCodec(r.Codec, nil).Marshal(r.Data)
+	if err != nil {
+		return nil, err
+	}
+	return toASCIIJSON(value), nil
+}
+
+// XMLRender renders a value as XML.
+type XMLRender struct {
+	Data interface{} // Value to encode
+}
+
+// ContentType returns the XML content type.
+func (r XMLRender) ContentType() string {
+	return "application/xml"
+}
+
+// Encode marshals the value with encoding/xml.
+func (r XMLRender) Encode() ([]byte, error) {
+	return xml.Marshal(r.Data)
+}
+
+// HTMLRender renders a named template.
+type HTMLRender struct {
+	Template *template.Template // Template set containing Name
+	Name     string             // Name of the template to execute
+	Data     interface{}        // Data passed to the template
+}
+
+// ContentType returns the HTML content type.
+func (r HTMLRender) ContentType() string {
+	return "text/html"
+}
+
+// Encode executes the template into a buffer.
+func (r HTMLRender) Encode() ([]byte, error) {
+	if r.Template == nil {
+		return nil, fmt.Errorf("tsweb: HTML templates are not loaded")
+	}
+	var buffer bytes.Buffer
+	if err := r.Template.ExecuteTemplate(&buffer, r.Name, r.Data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DataRender renders raw bytes.
+type DataRender struct {
+	Type string // Content type, left unset when empty
+	Data []byte // Raw body
+}
+
+// ContentType returns the configured content type.
+func (r DataRender) ContentType() string {
+	return r.Type
+}
+
+// Encode returns the raw body.
+func (r DataRender) Encode() ([]byte, error) {
+	return r.Data, nil
+}
@@ -0,0 +1,131 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContext_XML(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(w, req, NewEngine())
+
+	type student struct {
+		Name string `xml:"name"`
+	}
+	c.XML(http.StatusOK, student{Name: "tom"})
+	if ct := w.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Expected content type application/xml, got %s", ct)
+	}
+	if w.Body.String() != "<student><name>tom</name></student>" {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestContext_HTMLWithoutTemplates(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	c := makeContext(w, req, NewEngine())
+
+	// Rendering without loaded templates must fail cleanly instead of panicking
+	c.HTML(http.StatusOK, "index.tmpl", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"Empty", "", http.StatusOK, "application/json"},
+		{"Exact", "application/xml", http.StatusOK, "application/xml"},
+		{"Quality", "application/json;q=0.5, application/xml;q=0.9", http.StatusOK, "application/xml"},
+		{"Wildcard", "text/html, */*;q=0.1", http.StatusOK, "application/json"},
+		{"Excluded", "application/json;q=0, */*", http.StatusOK, "application/xml"},
+		{"NotAcceptable", "image/png", http.StatusNotAcceptable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine()
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Accept", tt.accept)
+			c := makeContext(w, req, engine)
+
+			type payload struct{ A int }
+			data := payload{A: 1}
+			c.Negotiate(http.StatusOK, JSONRender{Codec: engine.jsonCodec, Data: data}, XMLRender{Data: data})
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("Expected content type %s, got %s", tt.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestContext_NegotiateWithoutCodec(t *testing.T) {
+	engine := NewEngine()
+	codec := &upperCodec{}
+	engine.SetJSONCodec(codec)
+	engine.GET("/data", func(c *Context) {
+		// Offers built the way code outside the package must, without a codec
+		c.Negotiate(http.StatusOK, JSONRender{Data: H{"a": 1}}, XMLRender{Data: "a"})
+	})
+	engine.GET("/secure", func(c *Context) {
+		c.Render(http.StatusOK, SecureJSONRender{Data: []int{1}})
+	})
+	engine.GET("/unprefixed", func(c *Context) {
+		prefix := ""
+		c.Render(http.StatusOK, SecureJSONRender{Prefix: &prefix, Data: []int{1}})
+	})
+
+	req, _ := http.NewRequest("GET", "/data", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"a":1}` {
+		t.Errorf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if !codec.called {
+		t.Error("Expected the engine codec to be used")
+	}
+
+	req, _ = http.NewRequest("GET", "/secure", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != defaultSecureJSONPrefix+"[1]" {
+		t.Errorf("Expected engine prefix, got %s", w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/unprefixed", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "[1]" {
+		t.Errorf("Expected explicitly empty prefix to be kept, got %s", w.Body.String())
+	}
+
+	if body, err := (JSONRender{Data: 1}).Encode(); err != nil || string(body) != "1" {
+		t.Errorf("Expected default codec for direct Encode, got %s (%v)", body, err)
+	}
+}
+
+func TestContext_NegotiateFormat(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept", "text/*;q=0.8, text/html")
+	c := makeContext(nil, req, NewEngine())
+
+	if format := c.NegotiateFormat("text/plain", "text/html"); format != "text/html" {
+		t.Errorf("Expected text/html, got %s", format)
+	}
+	if format := c.NegotiateFormat("application/json"); format != "" {
+		t.Errorf("Expected no acceptable format, got %s", format)
+	}
+}
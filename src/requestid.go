@@ -0,0 +1,101 @@
+package tsweb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// RequestIDKey is the Context key under which the request ID is stored.
+const RequestIDKey = "tsweb.requestID"
+
+// defaultRequestIDHeader is the header read and written by the RequestID middleware.
+const defaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming request IDs so they cannot flood logs.
+const maxRequestIDLength = 128
+
+// crockfordAlphabet is the Crockford base32 alphabet used by ULIDs.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// RequestIDConfig configures the RequestID middleware.
+type RequestIDConfig struct {
+	Header    string        // Header carrying the ID, defaults to "X-Request-ID"
+	Generator func() string // Generator for new IDs, defaults to UUIDv4
+}
+
+// RequestID is a middleware that propagates the X-Request-ID header or generates a
+// UUIDv4. The ID is echoed in the response and included by Logger and Recovery.
+func RequestID() HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig is RequestID with a custom header or generator, such as ULID.
+func RequestIDWithConfig(config RequestIDConfig) HandlerFunc {
+	if config.Header == "" {
+		config.Header = defaultRequestIDHeader
+	}
+	if config.Generator == nil {
+		config.Generator = UUIDv4
+	}
+	return func(c *Context) {
+		id := c.Req.Header.Get(config.Header)
+		if !isValidRequestID(id) {
+			id = config.Generator()
+		}
+		c.Set(RequestIDKey, id)
+		c.SetHeader(config.Header, id)
+		c.Next()
+	}
+}
+
+// RequestID returns the ID assigned by the RequestID middleware, or "" if there is none.
+func (p *Context) RequestID() string {
+	return p.GetString(RequestIDKey)
+}
+
+// isValidRequestID reports whether an incoming ID is short printable ASCII without spaces.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for index := 0; index < len(id); index++ {
+		if id[index] <= ' ' || id[index] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// UUIDv4 returns a random RFC 4122 version 4 UUID.
+func UUIDv4() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(err)
+	}
+	uuid[6] = (uuid[6] & 0x0F) | 0x40
+	uuid[8] = (uuid[8] & 0x3F) | 0x80
+	encoded := hex.EncodeToString(uuid[:])
+	return encoded[0:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+}
+
+// ULID returns a lexicographically sortable identifier made of a millisecond timestamp
+// and 80 random bits, encoded as 26 Crockford base32 characters.
+func ULID() string {
+	var data [16]byte
+	binary.BigEndian.PutUint64(data[:8], uint64(time.Now().UnixMilli())<<16)
+	if _, err := rand.Read(data[6:]); err != nil {
+		panic(err)
+	}
+
+	// 128 bits are encoded as 26 characters of 5 bits, the first one holding 3 bits
+	high, low := binary.BigEndian.Uint64(data[:8]), binary.BigEndian.Uint64(data[8:])
+	encoded := make([]byte, 26)
+	for index := 25; index >= 0; index-- {
+		encoded[index] = crockfordAlphabet[low&0x1F]
+		low = low>>5 | high<<59
+		high >>= 5
+	}
+	return string(encoded)
+}
@@ -0,0 +1,83 @@
+package tsweb
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	engine := NewEngine()
+	engine.Use(RequestID())
+	engine.GET("/test", func(c *Context) {
+		c.String(http.StatusOK, c.RequestID())
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		echoed   bool
+	}{
+		{"Generated", "", false},
+		{"Propagated", "abc-123", true},
+		{"Rejected", "bad id\r\ninjected", false},
+	}
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-Request-ID", tt.incoming)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-ID")
+			if id != w.Body.String() {
+				t.Errorf("Expected echoed header %s to match context ID %s", id, w.Body.String())
+			}
+			if tt.echoed && id != tt.incoming {
+				t.Errorf("Expected incoming ID %s, got %s", tt.incoming, id)
+			}
+			if !tt.echoed && !uuidPattern.MatchString(id) {
+				t.Errorf("Expected generated UUIDv4, got %s", id)
+			}
+		})
+	}
+}
+
+func TestULID(t *testing.T) {
+	first, second := ULID(), ULID()
+	if len(first) != 26 || first == second {
+		t.Fatalf("Unexpected ULIDs: %s %s", first, second)
+	}
+	if strings.Trim(first, crockfordAlphabet) != "" || first[0] > '7' {
+		t.Errorf("Invalid ULID encoding: %s", first)
+	}
+}
+
+func TestRequestID_LoggerAndRecovery(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+
+	engine := NewEngine()
+	engine.Use(RequestIDWithConfig(RequestIDConfig{Generator: func() string { return "req-1" }}))
+	engine.Use(Logger())
+	engine.Use(Recovery())
+	engine.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, line := range []string{"[req-1] TSWeb start", "[req-1] Panic: boom", "[req-1] TSWeb end"} {
+		if !strings.Contains(buffer.String(), line) {
+			t.Errorf("Expected log line %q in %q", line, buffer.String())
+		}
+	}
+}
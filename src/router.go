@@ -1,7 +1,9 @@
 package tsweb
 
 import (
+	"net"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -13,6 +15,9 @@ type Router struct {
 	roots                 map[string]*node        // roots stores the root nodes for each HTTP method
 	handlerMap            map[string]HandlerFunc  // handlerMap stores the handler functions mapped to HTTP methods and patterns
 	handlerRouterGroupMap map[string]*RouterGroup // handlerRouterGroupMap stores the router groups mapped to HTTP methods and patterns
+	namedRoutes           map[string]*RouteInfo   // namedRoutes stores the routes registered with a name
+	hostRoot              *node                   // hostRoot stores the host patterns of host routers, split at dots
+	hosts                 map[string]*Router      // hosts stores the routers for routes restricted to a host pattern
 }
 
 // newRouter creates and returns a new Router instance.
@@ -21,6 +26,9 @@ func newRouter() *Router {
 		roots:                 make(map[string]*node),
 		handlerMap:            make(map[string]HandlerFunc),
 		handlerRouterGroupMap: make(map[string]*RouterGroup),
+		namedRoutes:           make(map[string]*RouteInfo),
+		hostRoot:              &node{},
+		hosts:                 make(map[string]*Router),
 	}
 }
 
@@ -41,8 +49,13 @@ func parsePattern(pattern string) []string {
 }
 
 // addRoute adds a route to the router for the specified HTTP method, pattern, handler, and router group.
-func (p *Router) addRoute(method string, pattern string, handler HandlerFunc, routerGroup *RouterGroup) {
+func (p *Router) addRoute(method string, pattern string, handler HandlerFunc, routerGroup *RouterGroup) *RouteInfo {
 	parts := parsePattern(pattern)
+	for index, part := range parts {
+		if isOptional(part) && index != len(parts)-1 {
+			panic("tsweb: only the last segment of " + pattern + " may be optional")
+		}
+	}
 
 	key := method + "-" + pattern
 	_, ok := p.roots[method]
@@ -52,6 +65,7 @@ func (p *Router) addRoute(method string, pattern string, handler HandlerFunc, ro
 	p.roots[method].insert(pattern, parts, 0)
 	p.handlerMap[key] = handler
 	p.handlerRouterGroupMap[key] = routerGroup
+	return &RouteInfo{Method: method, Pattern: pattern, router: p}
 }
 
 // getRoute retrieves the route matching the HTTP method and path, and extracts any URL parameters.
@@ -67,31 +81,161 @@ func (p *Router) getRoute(method string, path string) (*node, map[string]string)
 	n := root.search(searchParts, 0)
 
 	if n != nil {
-		parts := parsePattern(n.pattern)
-		for index, part := range parts {
-			if part[0] == ':' {
-				params[part[1:]] = searchParts[index]
-			}
-			if part[0] == '*' && len(part) > 1 {
-				params[part[1:]] = strings.Join(searchParts[index:], "/")
-				break
-			}
-		}
+		root.captureParams(parsePattern(n.pattern), searchParts, params)
 		return n, params
 	}
 	return nil, nil
 }
 
+// hostRouter returns the router for routes restricted to the host pattern, creating
+// it on first use. Static labels of the pattern are matched case-insensitively.
+func (p *Router) hostRouter(pattern string) *Router {
+	if pattern == "" {
+		return p
+	}
+	labels := splitHost(pattern)
+	for index, label := range labels {
+		if label == "" || label[0] == '*' {
+			panic("tsweb: invalid host pattern " + pattern)
+		}
+		if !strings.ContainsAny(label, ":{") {
+			labels[index] = strings.ToLower(label)
+		}
+	}
+	pattern = strings.Join(labels, ".")
+
+	router, ok := p.hosts[pattern]
+	if !ok {
+		router = newRouter()
+		router.namedRoutes = p.namedRoutes
+		p.hosts[pattern] = router
+		p.hostRoot.insert(pattern, labels, 0)
+	}
+	return router
+}
+
+// matchHost returns the host router whose pattern matches host, ignoring any port,
+// together with the parameters captured from the host.
+func (p *Router) matchHost(host string) (*Router, map[string]string) {
+	if len(p.hosts) == 0 {
+		return nil, nil
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	labels := splitHost(strings.TrimSuffix(strings.ToLower(host), "."))
+	n := p.hostRoot.search(labels, 0)
+	if n == nil {
+		return nil, nil
+	}
+	params := make(map[string]string)
+	p.hostRoot.captureParams(splitHost(n.pattern), labels, params)
+	return p.hosts[n.pattern], params
+}
+
+// splitHost splits a host or host pattern into its dot separated labels.
+func splitHost(host string) []string {
+	return strings.Split(host, ".")
+}
+
+// findCaseInsensitive returns path with its static segments in the case of a route
+// matching it case-insensitively, and whether such a route exists.
+func (p *Router) findCaseInsensitive(method string, path string) (string, bool) {
+	root, ok := p.roots[method]
+	if !ok {
+		return "", false
+	}
+	n, parts := root.searchFold(parsePattern(path), 0, nil)
+	if n == nil {
+		return "", false
+	}
+	fixed := "/" + strings.Join(parts, "/")
+	if strings.HasSuffix(path, "/") && fixed != "/" {
+		fixed += "/"
+	}
+	return fixed, true
+}
+
 // handle processes the incoming HTTP request by matching the route and invoking the appropriate handler.
+// Routes of a host router matching the request host take precedence over routes for any host.
 func (p *Router) handle(c *Context) {
-	n, params := p.getRoute(c.Method, c.Path)
+	path := c.engine.routePath(c.Req)
+	if router, hostParams := p.matchHost(c.Host()); router != nil && router.serve(c, path, hostParams) {
+		return
+	}
+	if !p.serve(c, path, nil) {
+		c.String(http.StatusNotFound, "404")
+	}
+}
+
+// serve runs the route matching the request and path, reporting whether there was one.
+// OPTIONS requests without an OPTIONS route are answered automatically when another method
+// matches the path, running that route's middleware first so CORS preflights can be handled.
+func (p *Router) serve(c *Context, path string, hostParams map[string]string) bool {
+	n, params := p.getRoute(c.Method, path)
 	if n != nil {
-		c.Params = params
+		c.Params = mergeParams(hostParams, c.engine.pathValues(params))
+		c.engine.setPathValues(c)
 		key := c.Method + "-" + n.pattern
 		c.handle = p.handlerMap[key]
 		c.middlewares = &p.handlerRouterGroupMap[key].middlewares
 		c.Next()
-	} else {
-		c.String(http.StatusNotFound, "404")
+		return true
+	}
+	if c.Method != http.MethodOptions {
+		return false
+	}
+	allowed, group, params := p.allowedMethods(path, c.Req.Header.Get("Access-Control-Request-Method"))
+	if len(allowed) == 0 {
+		return false
+	}
+	c.Params = mergeParams(hostParams, c.engine.pathValues(params))
+	c.engine.setPathValues(c)
+	c.handle = func(c *Context) {
+		c.SetHeader("Allow", strings.Join(allowed, ", "))
+		c.Status(http.StatusNoContent)
+	}
+	c.middlewares = &group.middlewares
+	c.Next()
+	return true
+}
+
+// mergeParams adds the host parameters to the path parameters, which take precedence.
+func mergeParams(hostParams map[string]string, params map[string]string) map[string]string {
+	for name, value := range hostParams {
+		if _, ok := params[name]; !ok {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// allowedMethods returns the sorted methods with a route matching path, plus OPTIONS,
+// together with the router group and parameters of the route for the preferred method,
+// such as the Access-Control-Request-Method of a preflight, or else of the first match.
+func (p *Router) allowedMethods(path string, preferred string) ([]string, *RouterGroup, map[string]string) {
+	methods := make([]string, 0, len(p.roots))
+	for method := range p.roots {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	allowed := make([]string, 0)
+	var group *RouterGroup
+	var groupParams map[string]string
+	for _, method := range methods {
+		n, params := p.getRoute(method, path)
+		if n == nil {
+			continue
+		}
+		allowed = append(allowed, method)
+		if group == nil || method == preferred {
+			group = p.handlerRouterGroupMap[method+"-"+n.pattern]
+			groupParams = params
+		}
+	}
+	if len(allowed) == 0 || group == nil {
+		return nil, nil, nil
 	}
+	return append(allowed, http.MethodOptions), group, groupParams
 }
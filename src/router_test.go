@@ -1,6 +1,8 @@
 package tsweb
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 )
@@ -109,3 +111,79 @@ func TestRouter_GetRoute(t *testing.T) {
 		t.Error("Failed to handle non-existent route")
 	}
 }
+
+// TestRouter_AutomaticOptions tests that OPTIONS requests are answered for registered paths.
+func TestRouter_AutomaticOptions(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/users/:id", func(c *Context) {})
+	engine.POST("/users/:id", func(c *Context) {})
+
+	req, _ := http.NewRequest("OPTIONS", "/users/1", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, POST, OPTIONS" {
+		t.Errorf("Unexpected response: %d Allow=%s", w.Code, w.Header().Get("Allow"))
+	}
+
+	req, _ = http.NewRequest("OPTIONS", "/missing", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRouter_HostRouting(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+	engine.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, "healthy")
+	})
+	api := engine.Host("API.example.com")
+	api.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+	tenants := engine.Host(":tenant.example.com")
+	tenants.Use(func(c *Context) {
+		c.SetHeader("X-Tenant", c.Param("tenant"))
+		c.Next()
+	})
+	tenants.Group("/users").GET("/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s user %s", c.Param("tenant"), c.Param("id"))
+	})
+
+	tests := []struct {
+		host   string
+		path   string
+		status int
+		body   string
+	}{
+		{"api.example.com", "/", http.StatusOK, "api"},
+		{"Api.Example.com:8080", "/", http.StatusOK, "api"},
+		{"acme.example.com", "/users/7", http.StatusOK, "acme user 7"},
+		{"acme.example.com", "/", http.StatusOK, "default"},
+		{"api.example.com", "/health", http.StatusOK, "healthy"},
+		{"example.com", "/users/7", http.StatusNotFound, "404"},
+		{"a.b.example.com", "/users/7", http.StatusNotFound, "404"},
+		{"other.org", "/", http.StatusOK, "default"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s%s: expected %d '%s', got %d '%s'", tt.host, tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/users/7", nil)
+	req.Host = "acme.example.com"
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if tenant := w.Header().Get("X-Tenant"); tenant != "acme" {
+		t.Errorf("Expected X-Tenant 'acme', got '%s'", tenant)
+	}
+}
@@ -0,0 +1,98 @@
+package tsweb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// RouteDescription describes a registered route as returned by Engine.Routes.
+type RouteDescription struct {
+	Host        string // Host pattern, empty for routes matching any host
+	Method      string // HTTP method
+	Pattern     string // Full URL pattern
+	Name        string // Route name, empty when unnamed
+	Handler     string // Fully qualified name of the handler function
+	GroupPrefix string // Prefix of the router group the route belongs to
+	Middlewares int    // Number of middlewares applied by the router group
+}
+
+// SetDebug enables or disables debug mode. In debug mode Run prints the route table.
+// Debug mode is enabled by default when the TSWEB_MODE environment variable is "debug".
+func (p *Engine) SetDebug(enabled bool) {
+	p.debug = enabled
+}
+
+// isDebugEnv reports whether the environment requests debug mode.
+func isDebugEnv() bool {
+	return os.Getenv("TSWEB_MODE") == "debug"
+}
+
+// Routes returns the registered routes sorted by host, pattern and method.
+func (p *Engine) Routes() []RouteDescription {
+	routes := p.router.describe("")
+	for host, router := range p.router.hosts {
+		routes = append(routes, router.describe(host)...)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// describe returns the routes registered with the router for the host pattern.
+func (p *Router) describe(host string) []RouteDescription {
+	names := make(map[string]string)
+	for name, route := range p.namedRoutes {
+		if route.router == p {
+			names[route.Method+"-"+route.Pattern] = name
+		}
+	}
+
+	routes := make([]RouteDescription, 0, len(p.handlerMap))
+	for key, handler := range p.handlerMap {
+		method, pattern, _ := strings.Cut(key, "-")
+		route := RouteDescription{
+			Host:    host,
+			Method:  method,
+			Pattern: pattern,
+			Name:    names[key],
+			Handler: handlerName(handler),
+		}
+		if group := p.handlerRouterGroupMap[key]; group != nil {
+			route.GroupPrefix = group.prefix
+			route.Middlewares = len(group.middlewares)
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// PrintRoutes writes the route table to w, one route per line.
+func (p *Engine) PrintRoutes(w io.Writer) {
+	for _, route := range p.Routes() {
+		line := fmt.Sprintf("[TSWeb-debug] %-7s %-30s --> %s (%d middlewares)", route.Method, route.Host+route.Pattern, route.Handler, route.Middlewares)
+		if route.Name != "" {
+			line += " name=" + route.Name
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// handlerName returns the fully qualified name of handler, or "" for nil handlers.
+func handlerName(handler HandlerFunc) string {
+	if handler == nil {
+		return ""
+	}
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
@@ -0,0 +1,46 @@
+package tsweb
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// listUsers is a named handler used to check handler names in the route table.
+func listUsers(c *Context) {}
+
+func TestEngine_Routes(t *testing.T) {
+	engine := NewEngine()
+	engine.Use(Logger())
+	engine.GET("/", listUsers)
+	api := engine.Group("/api")
+	api.Use(RequestID())
+	api.GET("/users", listUsers).Name("users")
+	api.POST("/users", listUsers)
+	engine.Host("admin.example.com").GET("/", listUsers).Name("admin")
+
+	expected := []RouteDescription{
+		{Method: "GET", Pattern: "/", Handler: "tsweb/src.listUsers", GroupPrefix: "", Middlewares: 1},
+		{Method: "GET", Pattern: "/api/users", Name: "users", Handler: "tsweb/src.listUsers", GroupPrefix: "/api", Middlewares: 2},
+		{Method: "POST", Pattern: "/api/users", Handler: "tsweb/src.listUsers", GroupPrefix: "/api", Middlewares: 2},
+		{Host: "admin.example.com", Method: "GET", Pattern: "/", Name: "admin", Handler: "tsweb/src.listUsers", GroupPrefix: "", Middlewares: 1},
+	}
+	if routes := engine.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("Unexpected routes:\n%+v\nexpected:\n%+v", routes, expected)
+	}
+}
+
+func TestEngine_PrintRoutes(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/users", listUsers).Name("users")
+
+	var buffer bytes.Buffer
+	engine.PrintRoutes(&buffer)
+	line := buffer.String()
+	for _, part := range []string{"GET", "/users", "tsweb/src.listUsers", "name=users"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("Expected %q in route table %q", part, line)
+		}
+	}
+}
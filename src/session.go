@@ -0,0 +1,285 @@
+package tsweb
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// maxCookieSize is the largest cookie value most browsers accept.
+const maxCookieSize = 4096
+
+// defaultSessionTTL is the session lifetime used by a MemoryStore created without a positive TTL.
+const defaultSessionTTL = 24 * time.Hour
+
+// Store loads and persists sessions. Implementations decide where session values live
+// and are responsible for writing the session cookie on Save and Destroy.
+type Store interface {
+	Load(c *Context, name string) (*Session, error) // Load returns the request's session or a new one
+	Save(c *Context, session *Session) error        // Save persists the session and writes its cookie
+	Destroy(c *Context, session *Session) error     // Destroy removes the session and its cookie
+}
+
+// Session holds the values of a single client session.
+type Session struct {
+	ID         string                 // Session identifier
+	Name       string                 // Name of the session cookie
+	Values     map[string]interface{} // Session values
+	IsNew      bool                   // Whether the session was created for this request
+	PreviousID string                 // Identifier replaced by Regenerate, removed by the store on Save
+	store      Store                  // Store the session was loaded from
+	ctx        *Context               // Context of the current request
+}
+
+// NewSession creates an empty session with a fresh random identifier.
+func NewSession(name string) *Session {
+	return &Session{
+		ID:     newSessionID(),
+		Name:   name,
+		Values: make(map[string]interface{}),
+		IsNew:  true,
+	}
+}
+
+// newSessionID returns a random, URL safe session identifier.
+func newSessionID() string {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// Get returns the session value stored under key.
+func (s *Session) Get(key string) interface{} {
+	return s.Values[key]
+}
+
+// Set stores a session value under key.
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+}
+
+// Delete removes the session value stored under key.
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+}
+
+// Save persists the session. It must be called before the response body is written.
+func (s *Session) Save() error {
+	return s.store.Save(s.ctx, s)
+}
+
+// Regenerate assigns a new identifier while keeping the values, protecting against
+// session fixation. Call it after a successful login and then Save the session.
+func (s *Session) Regenerate() {
+	if s.PreviousID == "" {
+		s.PreviousID = s.ID
+	}
+	s.ID = newSessionID()
+}
+
+// Destroy removes the session from the store and expires its cookie.
+func (s *Session) Destroy() error {
+	s.Values = make(map[string]interface{})
+	return s.store.Destroy(s.ctx, s)
+}
+
+// Sessions is a middleware that loads the session named name from store and makes it
+// available through Context.Session.
+func Sessions(name string, store Store) HandlerFunc {
+	return func(c *Context) {
+		session, err := store.Load(c, name)
+		if err != nil {
+			session = NewSession(name)
+		}
+		session.store = store
+		session.ctx = c
+		c.session = session
+		c.Next()
+	}
+}
+
+// Session returns the session loaded by the Sessions middleware, or nil if the
+// middleware is not installed.
+func (p *Context) Session() *Session {
+	return p.session
+}
+
+// CookieStore keeps session values in an encrypted cookie. The Engine must be
+// configured with SetCookieKeys. Values are encoded as JSON.
+type CookieStore struct{}
+
+// cookieSession is the JSON representation of a session stored in a cookie.
+type cookieSession struct {
+	ID     string                 `json:"id"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// NewCookieStore creates a store that keeps sessions in encrypted cookies.
+func NewCookieStore() *CookieStore {
+	return &CookieStore{}
+}
+
+// Load decrypts the session cookie, returning a new session if it is missing or invalid.
+func (s *CookieStore) Load(c *Context, name string) (*Session, error) {
+	value, err := c.EncryptedCookie(name)
+	if err != nil {
+		return NewSession(name), nil
+	}
+	var stored cookieSession
+	if err := json.Unmarshal([]byte(value), &stored); err != nil || stored.ID == "" {
+		return NewSession(name), nil
+	}
+	if stored.Values == nil {
+		stored.Values = make(map[string]interface{})
+	}
+	return &Session{ID: stored.ID, Name: name, Values: stored.Values}, nil
+}
+
+// Save encrypts the session into its cookie.
+func (s *CookieStore) Save(c *Context, session *Session) error {
+	data, err := json.Marshal(cookieSession{ID: session.ID, Values: session.Values})
+	if err != nil {
+		return err
+	}
+	encoded, err := c.engine.encryptCookie(session.Name, string(data))
+	if err != nil {
+		return err
+	}
+	if len(encoded) > maxCookieSize {
+		return errors.New("tsweb: session too large for cookie store")
+	}
+	c.SetCookie(session.Name, encoded)
+	session.PreviousID = ""
+	return nil
+}
+
+// Destroy expires the session cookie.
+func (s *CookieStore) Destroy(c *Context, session *Session) error {
+	c.DeleteCookie(session.Name)
+	return nil
+}
+
+// MemoryStore keeps session values in process memory and evicts sessions that have
+// not been saved within the TTL. The cookie only carries the session identifier.
+type MemoryStore struct {
+	ttl      time.Duration          // Lifetime of a session after its last save
+	mutex    sync.Mutex             // Guards sessions
+	sessions map[string]memoryEntry // Sessions by identifier
+	done     chan struct{}          // Closed to stop the cleanup goroutine
+}
+
+// memoryEntry is a session stored by a MemoryStore.
+type memoryEntry struct {
+	values  map[string]interface{} // Copy of the session values
+	expires time.Time              // Time after which the entry is evicted
+}
+
+// NewMemoryStore creates an in-memory store whose sessions expire after ttl, or after
+// 24 hours if ttl is not positive. Expired sessions are evicted periodically until
+// Close is called.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	s := &MemoryStore{
+		ttl:      ttl,
+		sessions: make(map[string]memoryEntry),
+		done:     make(chan struct{}),
+	}
+	go s.cleanup(ttl)
+	return s
+}
+
+// cleanup evicts expired sessions every interval until the store is closed.
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.deleteExpired(now)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// deleteExpired removes every session that expired before now.
+func (s *MemoryStore) deleteExpired(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id, entry := range s.sessions {
+		if now.After(entry.expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// Close stops the cleanup goroutine.
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+// Len returns the number of stored sessions.
+func (s *MemoryStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.sessions)
+}
+
+// Load looks up the session named by the cookie, returning a new session if it is
+// unknown or expired.
+func (s *MemoryStore) Load(c *Context, name string) (*Session, error) {
+	id, err := c.Cookie(name)
+	if err != nil {
+		return NewSession(name), nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.sessions[id]
+	if !ok || time.Now().After(entry.expires) {
+		return NewSession(name), nil
+	}
+	return &Session{ID: id, Name: name, Values: copyValues(entry.values)}, nil
+}
+
+// Save stores a copy of the session values, drops a regenerated identifier and
+// refreshes the session cookie.
+func (s *MemoryStore) Save(c *Context, session *Session) error {
+	s.mutex.Lock()
+	if session.PreviousID != "" {
+		delete(s.sessions, session.PreviousID)
+		session.PreviousID = ""
+	}
+	s.sessions[session.ID] = memoryEntry{values: copyValues(session.Values), expires: time.Now().Add(s.ttl)}
+	s.mutex.Unlock()
+
+	options := c.engine.cookieOptions
+	options.MaxAge = int(s.ttl / time.Second)
+	c.SetCookieWithOptions(session.Name, session.ID, options)
+	return nil
+}
+
+// Destroy removes the session and expires its cookie.
+func (s *MemoryStore) Destroy(c *Context, session *Session) error {
+	s.mutex.Lock()
+	delete(s.sessions, session.ID)
+	delete(s.sessions, session.PreviousID)
+	s.mutex.Unlock()
+	c.DeleteCookie(session.Name)
+	return nil
+}
+
+// copyValues returns a shallow copy of values.
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newSessionTestEngine creates an engine with login, read and logout routes backed by store.
+func newSessionTestEngine(store Store) *Engine {
+	engine := NewEngine()
+	engine.SetCookieKeys([]byte("0123456789abcdef"))
+	engine.Use(Sessions("session", store))
+	engine.GET("/login", func(c *Context) {
+		session := c.Session()
+		session.Regenerate()
+		session.Set("user", "tom")
+		if err := session.Save(); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, session.ID)
+	})
+	engine.GET("/me", func(c *Context) {
+		user, _ := c.Session().Get("user").(string)
+		c.String(http.StatusOK, user)
+	})
+	engine.GET("/logout", func(c *Context) {
+		c.Session().Destroy()
+		c.String(http.StatusOK, "bye")
+	})
+	return engine
+}
+
+// serveWithCookies performs a GET request carrying cookies and returns the recorder.
+func serveWithCookies(engine *Engine, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestSessions_Stores(t *testing.T) {
+	memoryStore := NewMemoryStore(time.Minute)
+	defer memoryStore.Close()
+
+	stores := map[string]Store{"Cookie": NewCookieStore(), "Memory": memoryStore}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			engine := newSessionTestEngine(store)
+
+			login := serveWithCookies(engine, "/login", nil)
+			if login.Code != http.StatusOK {
+				t.Fatalf("Login failed: %d %s", login.Code, login.Body.String())
+			}
+			cookies := login.Result().Cookies()
+
+			if me := serveWithCookies(engine, "/me", cookies); me.Body.String() != "tom" {
+				t.Errorf("Expected session user 'tom', got '%s'", me.Body.String())
+			}
+
+			logout := serveWithCookies(engine, "/logout", cookies)
+			if me := serveWithCookies(engine, "/me", logout.Result().Cookies()); me.Body.String() != "" {
+				t.Errorf("Expected empty session after logout, got '%s'", me.Body.String())
+			}
+		})
+	}
+}
+
+func TestSessions_RegenerateDropsFixatedID(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+	engine := newSessionTestEngine(store)
+
+	first := serveWithCookies(engine, "/login", nil)
+	oldID := first.Body.String()
+	second := serveWithCookies(engine, "/login", first.Result().Cookies())
+	newID := second.Body.String()
+
+	if oldID == newID {
+		t.Fatal("Expected Regenerate to assign a new session ID")
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected the old session to be removed, got %d sessions", store.Len())
+	}
+	fixated := []*http.Cookie{{Name: "session", Value: oldID}}
+	if me := serveWithCookies(engine, "/me", fixated); me.Body.String() != "" {
+		t.Errorf("Expected old session ID to be invalid, got '%s'", me.Body.String())
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+	engine := newSessionTestEngine(store)
+	serveWithCookies(engine, "/login", nil)
+
+	store.deleteExpired(time.Now().Add(30 * time.Second))
+	if store.Len() != 1 {
+		t.Errorf("Expected session to survive before TTL, got %d sessions", store.Len())
+	}
+	store.deleteExpired(time.Now().Add(2 * time.Minute))
+	if store.Len() != 0 {
+		t.Errorf("Expected session to be evicted after TTL, got %d sessions", store.Len())
+	}
+}
+
+func TestNewMemoryStore_DefaultTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		store := NewMemoryStore(ttl)
+		if store.ttl != defaultSessionTTL {
+			t.Errorf("NewMemoryStore(%v): expected TTL %v, got %v", ttl, defaultSessionTTL, store.ttl)
+		}
+		store.Close()
+	}
+}
@@ -0,0 +1,107 @@
+package tsweb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ServerSentEvent is a single event written to a text/event-stream response.
+type ServerSentEvent struct {
+	ID    string      // Event id, echoed back by clients as Last-Event-ID
+	Event string      // Event name, omitted when empty
+	Retry uint        // Reconnection delay in milliseconds, omitted when zero
+	Data  interface{} // Payload; strings and byte slices are sent as is, anything else as JSON
+}
+
+// sseFieldReplacer strips line breaks from single-line event fields.
+var sseFieldReplacer = strings.NewReplacer("\n", "", "\r", "")
+
+// encode writes the event in the text/event-stream wire format.
+func (e ServerSentEvent) encode(w io.Writer, codec JSONCodec) error {
+	var data []byte
+	switch value := e.Data.(type) {
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		encoded, err := codec.Marshal(value)
+		if err != nil {
+			return err
+		}
+		data = encoded
+	}
+
+	var buffer bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buffer, "id: %s\n", sseFieldReplacer.Replace(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buffer, "event: %s\n", sseFieldReplacer.Replace(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buffer, "retry: %s\n", strconv.FormatUint(uint64(e.Retry), 10))
+	}
+	// A lone \r also ends a line for SSE parsers, so it must not leak into a field
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&buffer, "data: %s\n", line)
+	}
+	buffer.WriteString("\n")
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// Stream calls step repeatedly, flushing after each call, until step returns false
+// or the client disconnects. It reports whether the client went away.
+func (p *Context) Stream(step func(w io.Writer) bool) bool {
+	done := p.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(p.Writer)
+			p.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// Flush sends any buffered response data to the client, if the writer supports it.
+func (p *Context) Flush() {
+	http.NewResponseController(p.Writer).Flush()
+}
+
+// SSEvent writes a named server-sent event carrying data and flushes it.
+func (p *Context) SSEvent(name string, data interface{}) error {
+	return p.WriteSSE(ServerSentEvent{Event: name, Data: data})
+}
+
+// WriteSSE writes a server-sent event and flushes it. The event stream headers and
+// a 200 status are sent before the first event unless a status was already set.
+func (p *Context) WriteSSE(event ServerSentEvent) error {
+	if p.StatusCode == 0 {
+		p.SetHeader("Content-Type", "text/event-stream")
+		p.SetHeader("Cache-Control", "no-cache")
+		p.SetHeader("Connection", "keep-alive")
+		p.Status(http.StatusOK)
+	}
+	if err := event.encode(p.Writer, p.engine.jsonCodec); err != nil {
+		return err
+	}
+	p.Flush()
+	return nil
+}
+
+// LastEventID returns the Last-Event-ID header sent by a reconnecting SSE client.
+func (p *Context) LastEventID() string {
+	return p.Req.Header.Get("Last-Event-ID")
+}
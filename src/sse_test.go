@@ -0,0 +1,88 @@
+package tsweb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContext_Stream(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	c := makeContext(w, req, NewEngine())
+
+	count := 0
+	clientGone := c.Stream(func(w io.Writer) bool {
+		count++
+		io.WriteString(w, "x")
+		return count < 3
+	})
+	if clientGone || w.Body.String() != "xxx" || !w.Flushed {
+		t.Errorf("Unexpected stream result: gone=%v body=%s flushed=%v", clientGone, w.Body.String(), w.Flushed)
+	}
+}
+
+func TestContext_StreamClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/stream", nil)
+	c := makeContext(w, req, NewEngine())
+
+	count := 0
+	clientGone := c.Stream(func(w io.Writer) bool {
+		count++
+		if count == 2 {
+			cancel()
+		}
+		return true
+	})
+	if !clientGone || count != 2 {
+		t.Errorf("Expected stream to stop after disconnect, gone=%v count=%d", clientGone, count)
+	}
+}
+
+func TestContext_WriteSSE(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	c := makeContext(w, req, NewEngine())
+
+	if c.LastEventID() != "41" {
+		t.Errorf("Expected Last-Event-ID 41, got %s", c.LastEventID())
+	}
+	c.WriteSSE(ServerSentEvent{ID: "42", Event: "update", Retry: 3000, Data: "line1\nline2"})
+	c.SSEvent("json", H{"a": 1})
+
+	expected := "id: 42\nevent: update\nretry: 3000\ndata: line1\ndata: line2\n\n" +
+		"event: json\ndata: {\"a\":1}\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected content type text/event-stream, got %s", ct)
+	}
+}
+
+func TestContext_WriteSSELineBreaks(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"CRLF", "a\r\nb", "data: a\ndata: b\n\n"},
+		{"LoneCR", "x\revent: evil", "data: x\ndata: event: evil\n\n"},
+		{"Mixed", "a\r\r\nb\nc", "data: a\ndata: \ndata: b\ndata: c\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/events", nil)
+			makeContext(w, req, NewEngine()).WriteSSE(ServerSentEvent{Data: tt.data})
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package tsweb
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutConfig configures the Timeout middleware.
+type TimeoutConfig struct {
+	Timeout time.Duration // Time the rest of the chain may run
+	Status  int           // Status sent on timeout, defaults to 503 Service Unavailable
+	Body    string        // Body sent on timeout, defaults to the status text
+}
+
+// Timeout is a middleware that gives the rest of the chain d to respond, using
+// TimeoutWithConfig with the default 503 response. Responses are buffered, so streaming,
+// SSE and WebSocket routes should not use it.
+func Timeout(d time.Duration) HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: d})
+}
+
+// TimeoutWithConfig is a middleware that runs the rest of the chain with a deadline on
+// Context.Req. The chain writes into a buffer that is sent once it finishes in time;
+// otherwise the timeout response is sent and late writes fail with http.ErrHandlerTimeout.
+// Handlers should watch c.Done() and return promptly once the deadline passes. Keys,
+// the principal, JWT claims, the session and the status code set by the chain are copied
+// back when it finishes in time. The buffer implements neither http.Flusher nor
+// http.Hijacker, so streaming and SSE responses are only sent once the chain returns and
+// WebSocket upgrades fail; keep such routes outside the middleware.
+func TimeoutWithConfig(config TimeoutConfig) HandlerFunc {
+	if config.Status == 0 {
+		config.Status = http.StatusServiceUnavailable
+	}
+	if config.Body == "" {
+		config.Body = http.StatusText(config.Status)
+	}
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Req.Context(), config.Timeout)
+		defer cancel()
+
+		// The chain runs on a copy so a late handler never touches this Context
+		writer := &timeoutWriter{header: make(http.Header)}
+		chain := c.Copy()
+		chain.Req = c.Req.WithContext(ctx)
+		chain.Writer = writer
+
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					panicked <- err
+				}
+			}()
+			chain.Next()
+			close(done)
+		}()
+
+		select {
+		case err := <-panicked:
+			panic(err)
+		case <-done:
+			writer.mutex.Lock()
+			defer writer.mutex.Unlock()
+			c.copyState(chain)
+			header := c.Writer.Header()
+			for key, values := range writer.header {
+				header[key] = values
+			}
+			if writer.status != 0 {
+				c.Status(writer.status)
+			}
+			c.Writer.Write(writer.buffer.Bytes())
+		case <-ctx.Done():
+			writer.mutex.Lock()
+			writer.timedOut = true
+			writer.mutex.Unlock()
+			c.Render(config.Status, DataRender{Type: "text/plain; charset=utf-8", Data: []byte(config.Body)})
+		}
+	}
+}
+
+// copyState brings the state the chain set on its copy back to the original Context.
+func (p *Context) copyState(chain *Context) {
+	chain.keysMutex.RLock()
+	keys := chain.keys
+	chain.keysMutex.RUnlock()
+	p.keysMutex.Lock()
+	p.keys = keys
+	p.keysMutex.Unlock()
+
+	p.StatusCode = chain.StatusCode
+	p.processIndex = chain.processIndex
+	p.session = chain.session
+	p.csrfToken = chain.csrfToken
+	p.principal = chain.principal
+	p.jwtClaims = chain.jwtClaims
+}
+
+// timeoutWriter buffers a response until the Timeout middleware decides its fate.
+type timeoutWriter struct {
+	mutex    sync.Mutex   // Guards all fields
+	header   http.Header  // Buffered headers
+	buffer   bytes.Buffer // Buffered body
+	status   int          // Buffered status code
+	timedOut bool         // Whether the timeout response has been sent
+}
+
+// Header returns the buffered headers.
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// Write buffers data, failing once the request has timed out.
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buffer.Write(data)
+}
+
+// WriteHeader records the first status code unless the request has timed out.
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timedOut || w.status != 0 {
+		return
+	}
+	w.status = status
+}
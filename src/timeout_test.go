@@ -0,0 +1,107 @@
+package tsweb
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTimeout_FastHandler(t *testing.T) {
+	engine := NewEngine()
+	engine.Use(Timeout(time.Second))
+	engine.GET("/fast", func(c *Context) {
+		if _, ok := c.Deadline(); !ok {
+			t.Error("Expected the request context to carry a deadline")
+		}
+		c.SetHeader("X-Handler", "fast")
+		c.String(http.StatusCreated, "done")
+	})
+
+	req, _ := http.NewRequest("GET", "/fast", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Body.String() != "done" || w.Header().Get("X-Handler") != "fast" {
+		t.Errorf("Unexpected response: %d %s %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestTimeout_SlowHandler(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	engine := NewEngine()
+	engine.Use(TimeoutWithConfig(TimeoutConfig{
+		Timeout: 20 * time.Millisecond,
+		Status:  http.StatusGatewayTimeout,
+		Body:    "too slow",
+	}))
+	engine.GET("/slow", func(c *Context) {
+		<-c.Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := c.Writer.Write([]byte("late"))
+		lateWrite <- err
+	})
+
+	req, _ := http.NewRequest("GET", "/slow", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusGatewayTimeout || w.Body.String() != "too slow" {
+		t.Errorf("Unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if err := <-lateWrite; err != http.ErrHandlerTimeout {
+		t.Errorf("Expected http.ErrHandlerTimeout for late write, got %v", err)
+	}
+	if w.Body.String() != "too slow" {
+		t.Errorf("Late write reached the response: %s", w.Body.String())
+	}
+}
+
+func TestTimeout_PanicReachesRecovery(t *testing.T) {
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(os.Stderr)
+
+	engine := NewEngine()
+	engine.Use(Recovery())
+	engine.Use(Timeout(time.Second))
+	engine.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestTimeout_CopiesStateBack(t *testing.T) {
+	var key interface{}
+	var principal string
+	var status int
+	engine := NewEngine()
+	engine.Use(func(c *Context) {
+		c.Next()
+		key, _ = c.Get("user")
+		principal = c.Principal()
+		status = c.StatusCode
+	})
+	engine.Use(Timeout(time.Second))
+	engine.GET("/state", func(c *Context) {
+		c.Set("user", "tom")
+		c.SetPrincipal("tom")
+		c.String(http.StatusAccepted, "ok")
+	})
+
+	req, _ := http.NewRequest("GET", "/state", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if key != "tom" || principal != "tom" {
+		t.Errorf("Expected key and principal 'tom', got '%v' and '%s'", key, principal)
+	}
+	if status != http.StatusAccepted {
+		t.Errorf("Expected status code %d, got %d", http.StatusAccepted, status)
+	}
+}
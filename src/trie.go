@@ -1,19 +1,235 @@
 package tsweb
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 // node represents a node in the trie structure used for routing.
 type node struct {
-	pattern  string  // pattern stores the part of the URL pattern associated with the node
-	part     string  // part stores the particular segment of the URL pattern
-	children []*node // children stores the child nodes of the current node
-	isWild   bool    // isWild is a flag indicating whether the part is a wildcard (e.g., :param or *wildcard)
+	pattern  string         // pattern stores the part of the URL pattern associated with the node
+	part     string         // part stores the particular segment of the URL pattern
+	children []*node        // children stores the child nodes of the current node
+	isWild   bool           // isWild is a flag indicating whether the part is a wildcard (e.g., :param or *wildcard)
+	names    []string       // names stores the parameters captured by the part, in order
+	mixed    bool           // mixed is set for parts combining parameters with literal text, e.g. :name.:ext
+	matcher  *regexp.Regexp // matcher constrains a parameter part, or captures the parameters of a mixed part
 }
 
-// matchChild finds and returns a child node that matches the given part.
+// segmentToken is a piece of a pattern part: either literal text or a parameter.
+type segmentToken struct {
+	literal    string // Literal text, empty for parameters
+	name       string // Parameter name, empty for literal text
+	constraint string // Regular expression constraining the parameter, empty if unconstrained
+}
+
+// newNode creates the trie node for a pattern part, compiling its parameter matcher.
+func newNode(part string) *node {
+	n := &node{part: part}
+	if part[0] == '*' {
+		n.isWild = true
+		return n
+	}
+	tokens := parseSegment(part)
+	if len(tokens) == 1 && tokens[0].name == "" {
+		return n
+	}
+	n.isWild = true
+	if len(tokens) == 1 {
+		n.names = []string{tokens[0].name}
+		if tokens[0].constraint != "" {
+			n.matcher = compileConstraint(tokens[0].constraint)
+		}
+		return n
+	}
+
+	n.mixed = true
+	var expr strings.Builder
+	for _, token := range tokens {
+		if token.name == "" {
+			expr.WriteString(regexp.QuoteMeta(token.literal))
+			continue
+		}
+		constraint := token.constraint
+		if constraint == "" {
+			constraint = ".+?"
+		}
+		n.names = append(n.names, token.name)
+		expr.WriteString("(?P<" + token.name + ">" + constraint + ")")
+	}
+	n.matcher = compileConstraint(expr.String())
+	return n
+}
+
+// isOptional reports whether part is an optional parameter such as :month?.
+func isOptional(part string) bool {
+	return len(part) > 1 && strings.HasSuffix(part, "?") && (part[0] == ':' || part[0] == '{')
+}
+
+// parseSegment splits a pattern part into literal text and parameters. Parameter
+// names end at the first character other than a letter, digit or underscore, so
+// :name, :name<constraint> and {name:regexp} parameters can be mixed with literal
+// text, as in :name.:ext, file-:id<int>.json or v{major:[0-9]+}.
+//
+// A part such as :user-id or :file.name, a single :name followed only by text, used
+// to capture the whole segment. It is ambiguous with mixed parts and panics when the
+// route is registered: write {user-id} for one parameter or {user}-id for a parameter
+// followed by text.
+func parseSegment(part string) []segmentToken {
+	if isOptional(part) {
+		part = part[:len(part)-1]
+	}
+
+	tokens := make([]segmentToken, 0)
+	literal := ""
+	for index := 0; index < len(part); {
+		token, end := parseParamToken(part, index)
+		if end < 0 {
+			literal += part[index : index+1]
+			index++
+			continue
+		}
+		if literal != "" {
+			tokens = append(tokens, segmentToken{literal: literal})
+			literal = ""
+		}
+		tokens = append(tokens, token)
+		index = end
+	}
+	if literal != "" || len(tokens) == 0 {
+		tokens = append(tokens, segmentToken{literal: literal})
+	}
+	if part[0] == ':' && len(tokens) == 2 && tokens[0].constraint == "" && tokens[1].name == "" {
+		panic(fmt.Sprintf("tsweb: ambiguous parameter segment %q, write {%s} for one parameter or {%s}%s for a parameter followed by text",
+			part, part[1:], tokens[0].name, tokens[1].literal))
+	}
+	return tokens
+}
+
+// parseParamToken parses the parameter starting at part[index], returning it and the
+// index following it, or -1 if no parameter starts there.
+func parseParamToken(part string, index int) (segmentToken, int) {
+	switch part[index] {
+	case ':':
+		end := index + 1
+		for end < len(part) && isNameChar(part[end]) {
+			end++
+		}
+		if end == index+1 {
+			return segmentToken{}, -1
+		}
+		token := segmentToken{name: part[index+1 : end]}
+		if end < len(part) && part[end] == '<' {
+			if close := strings.IndexByte(part[end:], '>'); close >= 0 {
+				token.constraint = constraintExpr(part[end : end+close+1])
+				end += close + 1
+			}
+		}
+		return token, end
+	case '{':
+		depth := 0
+		for end := index; end < len(part); end++ {
+			switch part[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					name, expr, _ := strings.Cut(part[index+1:end], ":")
+					return segmentToken{name: name, constraint: expr}, end + 1
+				}
+			}
+		}
+	}
+	return segmentToken{}, -1
+}
+
+// isNameChar reports whether b may appear in a parameter name.
+func isNameChar(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+// matches reports whether the node accepts the given part of a request path.
+func (n *node) matches(part string) bool {
+	if n.part == part {
+		return true
+	}
+	return n.isWild && (n.matcher == nil || n.matcher.MatchString(part))
+}
+
+// catchesAll reports whether the node accepts any path part, as unconstrained :name
+// and *name parts do. Two such siblings of the same kind would leave one unreachable.
+func (n *node) catchesAll() bool {
+	return n.isWild && !n.mixed && n.matcher == nil
+}
+
+// priority orders sibling nodes for matching: static parts first, then constrained and
+// mixed parameters, then catch-all :name parts and finally *name parts, so more specific
+// routes are tried before routes that accept any value, whatever the registration order.
+func (n *node) priority() int {
+	switch {
+	case !n.isWild:
+		return 0
+	case !n.catchesAll():
+		return 1
+	case n.part[0] != '*':
+		return 2
+	}
+	return 3
+}
+
+// addChild inserts child after the existing children of the same or higher priority.
+func (n *node) addChild(child *node) {
+	index := len(n.children)
+	for i, sibling := range n.children {
+		if sibling.priority() > child.priority() {
+			index = i
+			break
+		}
+	}
+	n.children = append(n.children, nil)
+	copy(n.children[index+1:], n.children[index:])
+	n.children[index] = child
+}
+
+// capture stores the parameters the node captures from the request path part value.
+func (n *node) capture(value string, params map[string]string) {
+	if !n.mixed {
+		params[n.names[0]] = value
+		return
+	}
+	match := n.matcher.FindStringSubmatch(value)
+	for _, name := range n.names {
+		params[name] = match[n.matcher.SubexpIndex(name)]
+	}
+}
+
+// captureParams walks the parts of a matched pattern from the root n and stores the
+// parameters they capture from searchParts in params.
+func (n *node) captureParams(parts []string, searchParts []string, params map[string]string) {
+	current := n
+	for index, part := range parts {
+		if index == len(searchParts) {
+			break // an omitted optional parameter
+		}
+		current = current.matchChild(part)
+		if part[0] == '*' {
+			if len(part) > 1 {
+				params[part[1:]] = strings.Join(searchParts[index:], "/")
+			}
+			break
+		}
+		if len(current.names) > 0 {
+			current.capture(searchParts[index], params)
+		}
+	}
+}
+
+// matchChild finds and returns the child node registered for the given pattern part.
 func (n *node) matchChild(part string) *node {
 	for _, child := range n.children {
-		if child.part == part || child.isWild {
+		if child.part == part {
 			return child
 		}
 	}
@@ -24,32 +240,49 @@ func (n *node) matchChild(part string) *node {
 func (n *node) matchChildren(part string) []*node {
 	nodes := make([]*node, 0)
 	for _, child := range n.children {
-		if child.part == part || child.isWild {
+		if child.matches(part) {
 			nodes = append(nodes, child)
 		}
 	}
 	return nodes
 }
 
-// insert inserts a pattern into the trie structure recursively.
+// insert inserts a pattern into the trie structure recursively. A trailing optional
+// parameter also makes the pattern match at the node before it.
 func (n *node) insert(pattern string, parts []string, height int) {
 	if len(parts) == height {
-		n.pattern = pattern
+		n.setPattern(pattern)
 		return
 	}
 
 	part := parts[height]
+	if height == len(parts)-1 && isOptional(part) {
+		n.setPattern(pattern)
+	}
 	child := n.matchChild(part)
 	if child == nil {
-		child = &node{
-			part:   part,
-			isWild: part[0] == ':' || part[0] == '*',
+		child = newNode(part)
+		if child.catchesAll() {
+			for _, sibling := range n.children {
+				if sibling.catchesAll() && sibling.priority() == child.priority() {
+					panic(fmt.Sprintf("tsweb: %s in %s conflicts with existing wildcard %s", part, pattern, sibling.part))
+				}
+			}
 		}
-		n.children = append(n.children, child)
+		n.addChild(child)
 	}
 	child.insert(pattern, parts, height+1)
 }
 
+// setPattern marks the node as matching pattern. It panics if the node already
+// matches a different pattern, e.g. /archive/:year and /archive/:year/:month?.
+func (n *node) setPattern(pattern string) {
+	if n.pattern != "" && n.pattern != pattern {
+		panic(fmt.Sprintf("tsweb: %s conflicts with existing route %s", pattern, n.pattern))
+	}
+	n.pattern = pattern
+}
+
 // search searches for a pattern in the trie structure recursively.
 func (n *node) search(parts []string, height int) *node {
 	if len(parts) == height || strings.HasPrefix(n.part, "*") {
@@ -69,3 +302,32 @@ func (n *node) search(parts []string, height int) *node {
 	}
 	return nil
 }
+
+// searchFold searches like search but compares static parts case-insensitively. It
+// also returns the path parts rewritten to the case used by the matching pattern.
+func (n *node) searchFold(parts []string, height int, fixed []string) (*node, []string) {
+	if len(parts) == height || strings.HasPrefix(n.part, "*") {
+		if n.pattern == "" {
+			return nil, nil
+		}
+		return n, append(fixed, parts[height:]...)
+	}
+	part := parts[height]
+
+	for _, child := range n.children {
+		corrected := part
+		if !child.isWild {
+			if !strings.EqualFold(child.part, part) {
+				continue
+			}
+			corrected = child.part
+		} else if !child.matches(part) {
+			continue
+		}
+		result, resultParts := child.searchFold(parts, height+1, append(fixed[:len(fixed):len(fixed)], corrected))
+		if result != nil {
+			return result, resultParts
+		}
+	}
+	return nil, nil
+}
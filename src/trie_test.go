@@ -1,6 +1,7 @@
 package tsweb
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -57,3 +58,195 @@ func TestNode_Search(t *testing.T) {
 		t.Errorf("Search failed, expected pattern: '/:name/world', got: '%s'", found.pattern)
 	}
 }
+
+func TestParseSegment(t *testing.T) {
+	tests := []struct {
+		part     string
+		expected []segmentToken
+	}{
+		{"hello", []segmentToken{{literal: "hello"}}},
+		{":name", []segmentToken{{name: "name"}}},
+		{"{user-id}", []segmentToken{{name: "user-id"}}},
+		{"{file.name}", []segmentToken{{name: "file.name"}}},
+		{"{name}.json", []segmentToken{{name: "name"}, {literal: ".json"}}},
+		{":id<int>.json", []segmentToken{{name: "id", constraint: `[0-9]+`}, {literal: ".json"}}},
+		{"::", []segmentToken{{literal: "::"}}},
+		{":id<int>", []segmentToken{{name: "id", constraint: `[0-9]+`}}},
+		{":month?", []segmentToken{{name: "month"}}},
+		{"{id:[0-9]{2}}", []segmentToken{{name: "id", constraint: `[0-9]{2}`}}},
+		{":name.:ext", []segmentToken{{name: "name"}, {literal: "."}, {name: "ext"}}},
+		{"file-:id.json", []segmentToken{{literal: "file-"}, {name: "id"}, {literal: ".json"}}},
+		{"v{major:[0-9]+}.{minor:[0-9]+}", []segmentToken{{literal: "v"}, {name: "major", constraint: `[0-9]+`}, {literal: "."}, {name: "minor", constraint: `[0-9]+`}}},
+		{":from-:to<int>", []segmentToken{{name: "from"}, {literal: "-"}, {name: "to", constraint: `[0-9]+`}}},
+		{"a{b", []segmentToken{{literal: "a{b"}}},
+	}
+	for _, tt := range tests {
+		if tokens := parseSegment(tt.part); !reflect.DeepEqual(tokens, tt.expected) {
+			t.Errorf("parseSegment(%q): expected %+v, got %+v", tt.part, tt.expected, tokens)
+		}
+	}
+}
+
+func TestParseSegment_Ambiguous(t *testing.T) {
+	for _, part := range []string{":file.name", ":user-id", ":name.json", ":user-id?"} {
+		t.Run(part, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic for ambiguous segment %q", part)
+				}
+			}()
+			parseSegment(part)
+		})
+	}
+}
+
+func TestNode_SearchMixedAndOptional(t *testing.T) {
+	r := newRouter()
+	patterns := []string{
+		"/files/:name.:ext",
+		"/files/readme",
+		"/reports/{name}.json",
+		"/reports/:id<int>.csv",
+		"/download/file-:id<int>.json",
+		"/archive/:year/:month?",
+		"/range/:from-:to",
+		"/api/v{major:[0-9]+}.{minor:[0-9]+}/status",
+		"/api/:version/status",
+		"/docs/:page?",
+		"/users/:id<int>/:tab?",
+	}
+	for _, pattern := range patterns {
+		r.addRoute("GET", pattern, nil, nil)
+	}
+
+	tests := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/files/report.pdf", "/files/:name.:ext", map[string]string{"name": "report", "ext": "pdf"}},
+		{"/files/archive.tar.gz", "/files/:name.:ext", map[string]string{"name": "archive", "ext": "tar.gz"}},
+		{"/files/readme", "/files/readme", map[string]string{}},
+		{"/files/.env", "", nil},
+		{"/reports/summary.json", "/reports/{name}.json", map[string]string{"name": "summary"}},
+		{"/reports/42.csv", "/reports/:id<int>.csv", map[string]string{"id": "42"}},
+		{"/reports/summary.xml", "", nil},
+		{"/reports/summary.csv", "", nil},
+		{"/download/file-42.json", "/download/file-:id<int>.json", map[string]string{"id": "42"}},
+		{"/download/file-x.json", "", nil},
+		{"/download/file-42.xml", "", nil},
+		{"/archive/2024/05", "/archive/:year/:month?", map[string]string{"year": "2024", "month": "05"}},
+		{"/archive/2024", "/archive/:year/:month?", map[string]string{"year": "2024"}},
+		{"/archive", "", nil},
+		{"/archive/2024/05/01", "", nil},
+		{"/range/1-10", "/range/:from-:to", map[string]string{"from": "1", "to": "10"}},
+		{"/api/v1.2/status", "/api/v{major:[0-9]+}.{minor:[0-9]+}/status", map[string]string{"major": "1", "minor": "2"}},
+		{"/api/beta/status", "/api/:version/status", map[string]string{"version": "beta"}},
+		{"/docs", "/docs/:page?", map[string]string{}},
+		{"/docs/intro", "/docs/:page?", map[string]string{"page": "intro"}},
+		{"/users/7", "/users/:id<int>/:tab?", map[string]string{"id": "7"}},
+		{"/users/7/posts", "/users/:id<int>/:tab?", map[string]string{"id": "7", "tab": "posts"}},
+		{"/users/tom", "", nil},
+	}
+	for _, tt := range tests {
+		n, params := r.getRoute("GET", tt.path)
+		if tt.pattern == "" {
+			if n != nil {
+				t.Errorf("%s: expected no match, got '%s'", tt.path, n.pattern)
+			}
+			continue
+		}
+		if n == nil || n.pattern != tt.pattern {
+			t.Errorf("%s: expected pattern '%s', got %v", tt.path, tt.pattern, n)
+			continue
+		}
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("%s: expected params %v, got %v", tt.path, tt.params, params)
+		}
+	}
+}
+
+func TestNode_InsertOptional(t *testing.T) {
+	root := &node{part: "/"}
+	root.insert("/archive/:year/:month?", []string{"archive", ":year", ":month?"}, 0)
+	year := root.children[0].children[0]
+	if year.pattern != "/archive/:year/:month?" {
+		t.Errorf("Insert failed, expected optional pattern on parent node, got: '%s'", year.pattern)
+	}
+	if month := year.children[0]; month.pattern != "/archive/:year/:month?" || !month.isWild {
+		t.Errorf("Insert failed, expected wild node with pattern, got: '%s'", month.pattern)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for optional parameter before the last segment")
+		}
+	}()
+	newRouter().addRoute("GET", "/archive/:year?/:month", nil, nil)
+}
+
+func TestNode_InsertConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		second   string
+		conflict bool
+	}{
+		{"SameParam", "/u/:id", "/u/:id/posts", false},
+		{"DifferentParams", "/u/:id", "/u/:name", true},
+		{"ParamAndWildcard", "/u/:id", "/u/*path", false},
+		{"Wildcards", "/u/*path", "/u/*rest", true},
+		{"ParamAndOptional", "/u/:id", "/u/:id?", true},
+		{"ConstrainedParams", "/u/:id<int>", "/u/:name<slug>", false},
+		{"ParamAndConstrained", "/u/:id", "/u/:n<int>", false},
+		{"ParamAndMixed", "/u/:id", "/u/:name.:ext", false},
+		{"ParamAndStatic", "/u/:id", "/u/new", false},
+		{"OptionalTakesOver", "/archive/:year", "/archive/:year/:month?", true},
+		{"OptionalTakenOver", "/archive/:year/:month?", "/archive/:year", true},
+		{"TrailingSlash", "/docs", "/docs/", true},
+		{"SamePattern", "/docs", "/docs", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if conflict := recover() != nil; conflict != tt.conflict {
+					t.Errorf("Expected conflict %v, got %v", tt.conflict, conflict)
+				}
+			}()
+			r := newRouter()
+			r.addRoute("GET", tt.first, nil, nil)
+			r.addRoute("GET", tt.second, nil, nil)
+		})
+	}
+}
+
+func TestNode_SearchPriority(t *testing.T) {
+	r := newRouter()
+	// Catch-all routes are registered first and must not shadow the specific ones
+	r.addRoute("GET", "/u/*path", nil, nil)
+	r.addRoute("GET", "/u/:id/posts", nil, nil)
+	r.addRoute("GET", "/u/:n<int>", nil, nil)
+	r.addRoute("GET", "/u/new", nil, nil)
+	r.addRoute("GET", "/u/:name.:ext", nil, nil)
+
+	tests := []struct {
+		path    string
+		pattern string
+	}{
+		{"/u/new", "/u/new"},
+		{"/u/42", "/u/:n<int>"},
+		{"/u/report.pdf", "/u/:name.:ext"},
+		{"/u/tom/posts", "/u/:id/posts"},
+		{"/u/tom", "/u/*path"},
+		{"/u/tom/likes", "/u/*path"},
+	}
+	for _, tt := range tests {
+		n, _ := r.getRoute("GET", tt.path)
+		if n == nil || n.pattern != tt.pattern {
+			t.Errorf("%s: expected pattern '%s', got %v", tt.path, tt.pattern, n)
+		}
+	}
+	if fixed, ok := r.findCaseInsensitive("GET", "/U/NEW"); !ok || fixed != "/u/new" {
+		t.Errorf("Expected case-insensitive match '/u/new', got '%s'", fixed)
+	}
+}
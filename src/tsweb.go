@@ -2,7 +2,9 @@ package tsweb
 
 import (
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"path"
 	"text/template"
 )
@@ -14,19 +16,41 @@ type RouterGroup struct {
 	parent      *RouterGroup      // Parent router group.
 	engine      *Engine           // Associated engine.
 	filePathMap map[string]string // Mapping of file paths.
+	host        string            // Host pattern the group's routes are restricted to, empty for any host.
 }
 
 // Engine is the web framework engine.
 type Engine struct {
-	*RouterGroup                     // Embedding RouterGroup for convenience.
-	router        *Router            // Router for handling HTTP requests.
-	htmlTemplates *template.Template // HTML template renderer.
-	funcMap       template.FuncMap   // FuncMap for HTML templates.
+	*RouterGroup                             // Embedding RouterGroup for convenience.
+	router                *Router            // Router for handling HTTP requests.
+	htmlTemplates         *template.Template // HTML template renderer.
+	funcMap               template.FuncMap   // FuncMap for HTML templates.
+	jsonCodec             JSONCodec          // Codec used to encode and decode JSON.
+	secureJSONPrefix      string             // Prefix written before SecureJSON responses.
+	cookieOptions         CookieOptions      // Default attributes for cookies set through a Context.
+	cookieKeys            []cookieKey        // Keys for signed and encrypted cookies, current key first.
+	trustedProxies        []*net.IPNet       // Proxies whose forwarding headers are trusted.
+	debug                 bool               // Whether debug output such as the route table is enabled.
+	redirectTrailingSlash bool               // Whether to redirect to the path with the route's trailing slash.
+	redirectFixedPath     bool               // Whether to redirect to the cleaned, case-corrected path.
+	removeExtraSlash      bool               // Whether to redirect paths containing repeated slashes.
+	useRawPath            bool               // Whether routes are matched against the escaped path.
+	unescapePathValues    bool               // Whether parameters matched against the escaped path are unescaped.
+	usePathValues         bool               // Whether route parameters are shared with Request.PathValue.
+	maxBodySize           int64              // Maximum number of bytes read from a request body.
 }
 
 // NewEngine creates a new Engine instance with an initialized router.
 func NewEngine() *Engine {
-	engine := &Engine{router: newRouter()}
+	engine := &Engine{
+		router:             newRouter(),
+		jsonCodec:          stdJSONCodec{},
+		secureJSONPrefix:   defaultSecureJSONPrefix,
+		cookieOptions:      defaultCookieOptions(),
+		debug:              isDebugEnv(),
+		unescapePathValues: true,
+		maxBodySize:        defaultMaxBodySize,
+	}
 	engine.RouterGroup = &RouterGroup{
 		engine:      engine,
 		prefix:      "",
@@ -45,6 +69,7 @@ func (r *RouterGroup) Group(prefix string) *RouterGroup {
 		engine:      r.engine,
 		middlewares: make([]HandlerFunc, len(r.middlewares)),
 		filePathMap: make(map[string]string),
+		host:        r.host,
 	}
 
 	if len(r.middlewares) > 0 {
@@ -53,25 +78,34 @@ func (r *RouterGroup) Group(prefix string) *RouterGroup {
 	return routerGroup
 }
 
+// Host creates a RouterGroup whose routes only match requests for the host pattern,
+// e.g. "api.example.com" or ":tenant.example.com", with host parameters read through
+// Context.Param. Other requests fall back to the routes registered without a host.
+func (r *RouterGroup) Host(pattern string) *RouterGroup {
+	group := r.Group("")
+	group.host = pattern
+	return group
+}
+
 // Use adds middleware handlers to the RouterGroup.
 func (r *RouterGroup) Use(handlerFunc HandlerFunc) {
 	r.middlewares = append(r.middlewares, handlerFunc)
 }
 
-// GET registers a GET request handler for the given URL pattern.
-func (r *RouterGroup) GET(url string, handlerFunc HandlerFunc) {
-	r.addRoute("GET", url, handlerFunc)
+// GET registers a GET request handler for the given URL pattern and returns the route.
+func (r *RouterGroup) GET(url string, handlerFunc HandlerFunc) *RouteInfo {
+	return r.addRoute("GET", url, handlerFunc)
 }
 
-// POST registers a POST request handler for the given URL pattern.
-func (r *RouterGroup) POST(url string, handlerFunc HandlerFunc) {
-	r.addRoute("POST", url, handlerFunc)
+// POST registers a POST request handler for the given URL pattern and returns the route.
+func (r *RouterGroup) POST(url string, handlerFunc HandlerFunc) *RouteInfo {
+	return r.addRoute("POST", url, handlerFunc)
 }
 
 // addRoute registers a request handler for the given HTTP method and URL pattern.
-func (r *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+func (r *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) *RouteInfo {
 	pattern := r.prefix + comp
-	r.engine.router.addRoute(method, pattern, handler, r)
+	return r.engine.router.hostRouter(r.host).addRoute(method, pattern, handler, r)
 }
 
 // createStaticHandler creates a handler function for serving static files.
@@ -96,19 +130,22 @@ func (r *RouterGroup) Static(url string, filePath string) {
 	r.GET(pattern, handler)
 }
 
-// GET registers a GET request handler with the Engine's router.
-func (p *Engine) GET(url string, handlerFunc HandlerFunc) {
-	p.router.addRoute("GET", url, handlerFunc, p.RouterGroup)
+// GET registers a GET request handler with the Engine's router and returns the route.
+func (p *Engine) GET(url string, handlerFunc HandlerFunc) *RouteInfo {
+	return p.router.addRoute("GET", url, handlerFunc, p.RouterGroup)
 }
 
-// POST registers a POST request handler with the Engine's router.
-func (p *Engine) POST(url string, handlerFunc HandlerFunc) {
-	p.router.addRoute("POST", url, handlerFunc, p.RouterGroup)
+// POST registers a POST request handler with the Engine's router and returns the route.
+func (p *Engine) POST(url string, handlerFunc HandlerFunc) *RouteInfo {
+	return p.router.addRoute("POST", url, handlerFunc, p.RouterGroup)
 }
 
 // ServeHTTP handles HTTP requests by passing them to the router.
 func (p *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c := makeContext(w, req, p)
+	if p.redirectFixed(c) {
+		return
+	}
 	p.router.handle(c)
 }
 
@@ -117,22 +154,48 @@ func (p *Engine) SetFuncMap(funcMap template.FuncMap) {
 	p.funcMap = funcMap
 }
 
+// SetJSONCodec replaces the codec used for JSON rendering and binding.
+func (p *Engine) SetJSONCodec(codec JSONCodec) {
+	p.jsonCodec = codec
+}
+
+// SetMaxBodySize sets the maximum number of bytes GetRawData, ParseForm and BindJSON
+// read from a request body. The default is 10 MB.
+func (p *Engine) SetMaxBodySize(size int64) {
+	p.maxBodySize = size
+}
+
+// SetSecureJSONPrefix sets the prefix written before SecureJSON responses.
+func (p *Engine) SetSecureJSONPrefix(prefix string) {
+	p.secureJSONPrefix = prefix
+}
+
 // LoadHTMLGlob loads HTML templates from the specified pattern.
+// The built-in url function builds paths of named routes, e.g. {{ url "user" .ID }}.
 func (p *Engine) LoadHTMLGlob(pattern string) {
-	p.htmlTemplates = template.Must(template.New("").Funcs(p.funcMap).ParseGlob(pattern))
+	funcMap := template.FuncMap{"url": p.URL}
+	for name, function := range p.funcMap {
+		funcMap[name] = function
+	}
+	p.htmlTemplates = template.Must(template.New("").Funcs(funcMap).ParseGlob(pattern))
 }
 
 // Run starts the HTTP server and listens for incoming requests on the specified port.
+// In debug mode the route table is printed first.
 func (p *Engine) Run(port string) {
+	if p.debug {
+		p.PrintRoutes(os.Stdout)
+	}
 	http.ListenAndServe(port, p)
 }
 
 // Logger is a middleware handler that logs the start and end of each request.
+// Log lines are prefixed with the request ID when the RequestID middleware is used.
 func Logger() HandlerFunc {
 	return func(c *Context) {
-		log.Printf("TSWeb start")
+		log.Printf("%sTSWeb start", requestIDPrefix(c))
 		c.Next()
-		log.Printf("TSWeb end")
+		log.Printf("%sTSWeb end", requestIDPrefix(c))
 	}
 }
 
@@ -142,7 +205,7 @@ func Recovery() HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				// Log the error
-				log.Printf("Panic: %v", err)
+				log.Printf("%sPanic: %v", requestIDPrefix(c), err)
 
 				// Set appropriate HTTP status code
 				c.Status(http.StatusInternalServerError)
@@ -155,3 +218,11 @@ func Recovery() HandlerFunc {
 		c.Next()
 	}
 }
+
+// requestIDPrefix returns "[id] " for requests carrying a request ID, or "" otherwise.
+func requestIDPrefix(c *Context) string {
+	if id := c.RequestID(); id != "" {
+		return "[" + id + "] "
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package tsweb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RouteInfo describes a registered route and allows it to be named for URL generation.
+type RouteInfo struct {
+	Method  string  // HTTP method of the route
+	Pattern string  // Full URL pattern, including the group prefix
+	name    string  // Route name, empty when unnamed
+	router  *Router // Router the route is registered with
+}
+
+// Name assigns a name to the route so Engine.URL and the url template function can
+// build its path. It panics if the name is already used by another route.
+func (r *RouteInfo) Name(name string) *RouteInfo {
+	if existing, ok := r.router.namedRoutes[name]; ok && existing != r {
+		panic(fmt.Sprintf("tsweb: route name %q already used by %s %s", name, existing.Method, existing.Pattern))
+	}
+	delete(r.router.namedRoutes, r.name)
+	r.name = name
+	r.router.namedRoutes[name] = r
+	return r
+}
+
+// GetName returns the name of the route, or "" if it is unnamed.
+func (r *RouteInfo) GetName() string {
+	return r.name
+}
+
+// URL builds the path of the named route, substituting params in order for its
+// parameters and *name segment. A trailing optional parameter is dropped when no value
+// is left for it. It fails for unknown names, missing or extra params and values
+// violating a parameter constraint.
+func (p *Engine) URL(name string, params ...interface{}) (string, error) {
+	route, ok := p.router.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("tsweb: no route named %q", name)
+	}
+
+	segments := strings.Split(route.Pattern, "/")
+	used := 0
+	for index, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		if segment[0] == '*' {
+			if used >= len(params) {
+				return "", fmt.Errorf("tsweb: missing value for parameter %q of route %q", segment[1:], name)
+			}
+			escaped := strings.Split(fmt.Sprint(params[used]), "/")
+			used++
+			for i, part := range escaped {
+				escaped[i] = url.PathEscape(part)
+			}
+			segments[index] = strings.Join(escaped, "/")
+			segments = segments[:index+1]
+			break
+		}
+		tokens := parseSegment(segment)
+		if len(tokens) == 1 && tokens[0].name == "" {
+			continue
+		}
+		if isOptional(segment) && used == len(params) {
+			segments = segments[:index]
+			break
+		}
+		var built strings.Builder
+		for _, token := range tokens {
+			if token.name == "" {
+				built.WriteString(token.literal)
+				continue
+			}
+			if used >= len(params) {
+				return "", fmt.Errorf("tsweb: missing value for parameter %q of route %q", token.name, name)
+			}
+			value := fmt.Sprint(params[used])
+			used++
+			if value == "" {
+				return "", fmt.Errorf("tsweb: empty value for parameter %q of route %q", token.name, name)
+			}
+			if token.constraint != "" && !compileConstraint(token.constraint).MatchString(value) {
+				return "", fmt.Errorf("tsweb: value %q violates the constraint of parameter %q of route %q", value, token.name, name)
+			}
+			built.WriteString(url.PathEscape(value))
+		}
+		segments[index] = built.String()
+	}
+	if used != len(params) {
+		return "", fmt.Errorf("tsweb: too many parameters for route %q", name)
+	}
+	return strings.Join(segments, "/"), nil
+}
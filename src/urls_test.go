@@ -0,0 +1,97 @@
+package tsweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newURLTestEngine creates an engine with named routes inside and outside groups.
+func newURLTestEngine() *Engine {
+	engine := NewEngine()
+	handler := func(c *Context) {}
+	engine.GET("/", handler).Name("home")
+	engine.GET("/hello/:name", handler).Name("hello")
+	v2 := engine.Group("/v2")
+	v2.GET("/users/:id/posts/:post", handler).Name("post")
+	engine.Static("/assets", "../static")
+	engine.GET("/files/*filepath", handler).Name("file")
+	engine.GET("/download/:name.:ext", handler).Name("download")
+	engine.GET("/archive/:year<int>/:month?", handler).Name("archive")
+	return engine
+}
+
+func TestEngine_URL(t *testing.T) {
+	engine := newURLTestEngine()
+
+	tests := []struct {
+		name     string
+		params   []interface{}
+		expected string
+	}{
+		{"home", nil, "/"},
+		{"hello", []interface{}{"tom"}, "/hello/tom"},
+		{"hello", []interface{}{"a b/c"}, "/hello/a%20b%2Fc"},
+		{"post", []interface{}{42, "intro"}, "/v2/users/42/posts/intro"},
+		{"file", []interface{}{"css/main file.css"}, "/files/css/main%20file.css"},
+		{"download", []interface{}{"report", "pdf"}, "/download/report.pdf"},
+		{"archive", []interface{}{2024, 5}, "/archive/2024/5"},
+		{"archive", []interface{}{2024}, "/archive/2024"},
+	}
+	for _, tt := range tests {
+		url, err := engine.URL(tt.name, tt.params...)
+		if err != nil || url != tt.expected {
+			t.Errorf("URL(%q, %v): expected %s, got %s (%v)", tt.name, tt.params, tt.expected, url, err)
+		}
+	}
+
+	errorCases := []struct {
+		name   string
+		params []interface{}
+	}{
+		{"missing", nil},
+		{"post", []interface{}{42}},
+		{"hello", []interface{}{"tom", "extra"}},
+		{"hello", []interface{}{""}},
+		{"download", []interface{}{"report"}},
+		{"archive", []interface{}{"latest"}},
+	}
+	for _, tt := range errorCases {
+		if _, err := engine.URL(tt.name, tt.params...); err == nil {
+			t.Errorf("URL(%q, %v): expected error", tt.name, tt.params)
+		}
+	}
+}
+
+func TestRouteInfo_DuplicateName(t *testing.T) {
+	engine := newURLTestEngine()
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected duplicate route name to panic")
+		}
+	}()
+	engine.POST("/login", func(c *Context) {}).Name("hello")
+}
+
+func TestEngine_URLTemplateFunc(t *testing.T) {
+	dir := t.TempDir()
+	content := `<a href="{{ url "hello" .name }}">{{ .name }}</a>`
+	if err := os.WriteFile(filepath.Join(dir, "link.tmpl"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := newURLTestEngine()
+	engine.LoadHTMLGlob(filepath.Join(dir, "*"))
+	engine.GET("/link", func(c *Context) {
+		c.HTML(http.StatusOK, "link.tmpl", H{"name": "tom"})
+	})
+
+	req, _ := http.NewRequest("GET", "/link", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != `<a href="/hello/tom">tom</a>` {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+}
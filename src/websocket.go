@@ -0,0 +1,445 @@
+package tsweb
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// websocketGUID is the magic value appended to Sec-WebSocket-Key (RFC 6455, section 1.3).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// defaultWebSocketReadLimit is the maximum size of a message read by a WebSocketConn.
+const defaultWebSocketReadLimit = 16 << 20
+
+// WebSocket opcodes (RFC 6455, section 11.8).
+const (
+	continuationFrame = 0  // continuationFrame continues a fragmented message
+	TextMessage       = 1  // TextMessage denotes a UTF-8 encoded text message
+	BinaryMessage     = 2  // BinaryMessage denotes a binary message
+	CloseMessage      = 8  // CloseMessage denotes a close control frame
+	PingMessage       = 9  // PingMessage denotes a ping control frame
+	PongMessage       = 10 // PongMessage denotes a pong control frame
+)
+
+// WebSocket close codes (RFC 6455, section 7.4.1).
+const (
+	CloseNormalClosure           = 1000 // CloseNormalClosure indicates a normal closure
+	CloseGoingAway               = 1001 // CloseGoingAway indicates an endpoint going away
+	CloseProtocolError           = 1002 // CloseProtocolError indicates a protocol violation
+	CloseUnsupportedData         = 1003 // CloseUnsupportedData indicates an unacceptable data type
+	CloseNoStatusReceived        = 1005 // CloseNoStatusReceived is reported when a close frame has no code
+	CloseInvalidFramePayloadData = 1007 // CloseInvalidFramePayloadData indicates invalid UTF-8 text
+	ClosePolicyViolation         = 1008 // ClosePolicyViolation indicates a policy violation
+	CloseMessageTooBig           = 1009 // CloseMessageTooBig indicates a message over the read limit
+	CloseInternalServerErr       = 1011 // CloseInternalServerErr indicates an unexpected server condition
+)
+
+// errWebSocketCloseSent is returned when writing after a close frame has been sent.
+var errWebSocketCloseSent = errors.New("tsweb: websocket close frame already sent")
+
+// CloseError is returned by ReadMessage when the connection has been closed.
+type CloseError struct {
+	Code int    // Close code sent by the peer or used to fail the connection
+	Text string // Close reason
+}
+
+// Error returns a description of the close.
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("tsweb: websocket closed with code %d: %s", e.Code, e.Text)
+}
+
+// WebSocketHandler handles an upgraded WebSocket connection.
+type WebSocketHandler func(*Context, *WebSocketConn)
+
+// WebSocketUpgrader performs the server side of the WebSocket opening handshake.
+// The zero value accepts same-origin requests without a subprotocol.
+type WebSocketUpgrader struct {
+	Subprotocols []string                 // Subprotocols supported by the server, in order of preference
+	CheckOrigin  func(*http.Request) bool // Origin check, defaults to same-origin when nil
+	ReadLimit    int64                    // Maximum message size, defaults to 16MB when zero
+}
+
+// Upgrade validates the handshake, hijacks the connection and switches protocols.
+// On failure an HTTP error response has already been sent.
+func (u *WebSocketUpgrader) Upgrade(c *Context) (*WebSocketConn, error) {
+	r := c.Req
+	if r.Method != http.MethodGet {
+		return nil, upgradeError(c, http.StatusMethodNotAllowed, "method is not GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, upgradeError(c, http.StatusBadRequest, "missing upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.SetHeader("Sec-WebSocket-Version", "13")
+		return nil, upgradeError(c, http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, upgradeError(c, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = isSameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, upgradeError(c, http.StatusForbidden, "origin not allowed")
+	}
+
+	netConn, rw, err := http.NewResponseController(c.Writer).Hijack()
+	if err != nil {
+		return nil, upgradeError(c, http.StatusInternalServerError, "connection cannot be hijacked")
+	}
+	netConn.SetDeadline(time.Time{})
+
+	subprotocol := u.selectSubprotocol(r)
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + computeAcceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	if _, err := netConn.Write([]byte(response + "\r\n")); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	c.StatusCode = http.StatusSwitchingProtocols
+
+	readLimit := u.ReadLimit
+	if readLimit <= 0 {
+		readLimit = defaultWebSocketReadLimit
+	}
+	return &WebSocketConn{
+		conn:        netConn,
+		reader:      rw.Reader,
+		readLimit:   readLimit,
+		subprotocol: subprotocol,
+	}, nil
+}
+
+// Handler returns a HandlerFunc that upgrades the request and passes the connection
+// to handler, closing it once handler returns.
+func (u *WebSocketUpgrader) Handler(handler WebSocketHandler) HandlerFunc {
+	return func(c *Context) {
+		conn, err := u.Upgrade(c)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handler(c, conn)
+	}
+}
+
+// selectSubprotocol returns the first server subprotocol requested by the client.
+func (u *WebSocketUpgrader) selectSubprotocol(r *http.Request) string {
+	for _, supported := range u.Subprotocols {
+		if headerContainsToken(r.Header, "Sec-WebSocket-Protocol", supported) {
+			return supported
+		}
+	}
+	return ""
+}
+
+// upgradeError sends an HTTP error response for a failed handshake.
+func upgradeError(c *Context, status int, message string) error {
+	c.Error(status, http.StatusText(status))
+	return errors.New("tsweb: websocket handshake failed: " + message)
+}
+
+// headerContainsToken reports whether the comma separated header contains token.
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSameOrigin reports whether the Origin header, if any, matches the request host.
+func isSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// computeAcceptKey computes the Sec-WebSocket-Accept value for key.
+func computeAcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// Upgrade upgrades the request to a WebSocket connection using the default upgrader.
+func (p *Context) Upgrade() (*WebSocketConn, error) {
+	return (&WebSocketUpgrader{}).Upgrade(p)
+}
+
+// WebSocket registers a WebSocket endpoint. Group middleware runs before the upgrade,
+// so it can reject the request with a regular HTTP response.
+func (r *RouterGroup) WebSocket(url string, handler WebSocketHandler) *RouteInfo {
+	return r.GET(url, (&WebSocketUpgrader{}).Handler(handler))
+}
+
+// WebSocketConn is a server side WebSocket connection. Reads must happen from a single
+// goroutine; writes are safe for concurrent use.
+type WebSocketConn struct {
+	conn        net.Conn      // Underlying hijacked connection
+	reader      *bufio.Reader // Buffered reader over conn
+	readLimit   int64         // Maximum message size
+	subprotocol string        // Negotiated subprotocol
+	pongHandler func([]byte)  // Optional callback for received pongs
+	writeMutex  sync.Mutex    // Serializes frame writes
+	closeSent   bool          // Whether a close frame has been written
+}
+
+// wsFrame is a single decoded WebSocket frame.
+type wsFrame struct {
+	fin     bool   // Final fragment flag
+	opcode  int    // Frame opcode
+	payload []byte // Unmasked payload
+}
+
+// Subprotocol returns the negotiated subprotocol, or "" if none was selected.
+func (w *WebSocketConn) Subprotocol() string {
+	return w.subprotocol
+}
+
+// SetPongHandler sets a callback invoked with the payload of each received pong.
+func (w *WebSocketConn) SetPongHandler(handler func(data []byte)) {
+	w.pongHandler = handler
+}
+
+// SetReadDeadline sets the deadline for future reads on the connection.
+func (w *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return w.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future writes on the connection.
+func (w *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return w.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage reads the next complete data message, reassembling fragments. Pings are
+// answered automatically. A close frame is echoed and reported as a *CloseError.
+func (w *WebSocketConn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	message := make([]byte, 0)
+	for {
+		frame, err := w.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch frame.opcode {
+		case PingMessage:
+			if err := w.writeFrame(PongMessage, frame.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if w.pongHandler != nil {
+				w.pongHandler(frame.payload)
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, w.handleClose(frame.payload)
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, w.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, w.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = frame.opcode
+		default:
+			return 0, nil, w.fail(CloseProtocolError, "reserved opcode")
+		}
+
+		if int64(len(message)+len(frame.payload)) > w.readLimit {
+			return 0, nil, w.fail(CloseMessageTooBig, "message too big")
+		}
+		message = append(message, frame.payload...)
+		if frame.fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				return 0, nil, w.fail(CloseInvalidFramePayloadData, "invalid UTF-8 text")
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame reads and unmasks a single frame, enforcing the server side framing rules.
+func (w *WebSocketConn) readFrame() (*wsFrame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(w.reader, header[:]); err != nil {
+		return nil, err
+	}
+	frame := &wsFrame{fin: header[0]&0x80 != 0, opcode: int(header[0] & 0x0F)}
+	if header[0]&0x70 != 0 {
+		return nil, w.fail(CloseProtocolError, "reserved bits set")
+	}
+	if header[1]&0x80 == 0 {
+		return nil, w.fail(CloseProtocolError, "client frame is not masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(w.reader, extended[:]); err != nil {
+			return nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(w.reader, extended[:]); err != nil {
+			return nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+		if length>>63 != 0 {
+			return nil, w.fail(CloseProtocolError, "invalid payload length")
+		}
+	}
+	if frame.opcode >= CloseMessage && (!frame.fin || length > 125) {
+		return nil, w.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length > uint64(w.readLimit) {
+		return nil, w.fail(CloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(w.reader, mask[:]); err != nil {
+		return nil, err
+	}
+	frame.payload = make([]byte, length)
+	if _, err := io.ReadFull(w.reader, frame.payload); err != nil {
+		return nil, err
+	}
+	for index := range frame.payload {
+		frame.payload[index] ^= mask[index%4]
+	}
+	return frame, nil
+}
+
+// handleClose answers a close frame from the peer and closes the connection.
+func (w *WebSocketConn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return w.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !isValidReceivedCloseCode(closeErr.Code) {
+			return w.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.Valid(payload[2:]) {
+			return w.fail(CloseInvalidFramePayloadData, "invalid UTF-8 close reason")
+		}
+	}
+
+	if closeErr.Code == CloseNoStatusReceived {
+		w.writeFrame(CloseMessage, nil)
+	} else {
+		w.WriteClose(closeErr.Code, "")
+	}
+	w.conn.Close()
+	return closeErr
+}
+
+// isValidReceivedCloseCode reports whether code may appear in a received close frame.
+func isValidReceivedCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+// fail sends a close frame with code, closes the connection and returns a *CloseError.
+func (w *WebSocketConn) fail(code int, text string) error {
+	w.WriteClose(code, text)
+	w.conn.Close()
+	return &CloseError{Code: code, Text: text}
+}
+
+// WriteMessage writes data as a single unfragmented text or binary message.
+func (w *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("tsweb: invalid websocket message type %d", messageType)
+	}
+	return w.writeFrame(messageType, data)
+}
+
+// Ping sends a ping control frame with the given application data.
+func (w *WebSocketConn) Ping(data []byte) error {
+	if len(data) > 125 {
+		return errors.New("tsweb: websocket control frame payload too large")
+	}
+	return w.writeFrame(PingMessage, data)
+}
+
+// WriteClose sends a close frame with the given code and reason.
+func (w *WebSocketConn) WriteClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	return w.writeFrame(CloseMessage, payload)
+}
+
+// Close sends a normal closure frame, if none was sent yet, and closes the connection.
+func (w *WebSocketConn) Close() error {
+	w.WriteClose(CloseNormalClosure, "")
+	return w.conn.Close()
+}
+
+// writeFrame writes a single unmasked final frame.
+func (w *WebSocketConn) writeFrame(opcode int, payload []byte) error {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	if w.closeSent {
+		return errWebSocketCloseSent
+	}
+	if opcode == CloseMessage {
+		w.closeSent = true
+	}
+
+	frame := make([]byte, 0, 10+len(payload))
+	frame = append(frame, 0x80|byte(opcode))
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, payload...)
+	_, err := w.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,205 @@
+package tsweb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWebSocketKey is the sample nonce from RFC 6455, section 1.3.
+const testWebSocketKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// newWebSocketTestServer starts a server with an echo endpoint behind an auth middleware.
+func newWebSocketTestServer(t *testing.T) *httptest.Server {
+	engine := NewEngine()
+	group := engine.Group("/ws")
+	group.Use(func(c *Context) {
+		if c.Query("token") != "secret" {
+			c.String(http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		c.Next()
+	})
+	group.WebSocket("/echo", func(c *Context, conn *WebSocketConn) {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	})
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialWebSocket performs the opening handshake and returns the connection and response.
+func dialWebSocket(t *testing.T, server *httptest.Server, path string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request := "GET " + path + " HTTP/1.1\r\nHost: " + conn.RemoteAddr().String() + "\r\n" +
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + testWebSocketKey + "\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, reader, resp
+}
+
+// writeClientFrame writes a masked frame as a client would.
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode int, payload []byte) {
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for index, b := range payload {
+		frame = append(frame, b^mask[index%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads a single unmasked frame with a short payload.
+func readServerFrame(t *testing.T, reader *bufio.Reader) (int, []byte) {
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, header[1]&0x7F)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatal(err)
+	}
+	return int(header[0] & 0x0F), payload
+}
+
+func TestWebSocket_Handshake(t *testing.T) {
+	server := newWebSocketTestServer(t)
+	_, _, resp := dialWebSocket(t, server, "/ws/echo?token=secret")
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	// Expected value from RFC 6455, section 1.3
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected Sec-WebSocket-Accept: %s", accept)
+	}
+}
+
+func TestWebSocket_MiddlewareBeforeUpgrade(t *testing.T) {
+	server := newWebSocketTestServer(t)
+	_, _, resp := dialWebSocket(t, server, "/ws/echo")
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestWebSocket_BadHandshake(t *testing.T) {
+	engine := NewEngine()
+	engine.WebSocket("/echo", func(c *Context, conn *WebSocketConn) {})
+
+	req, _ := http.NewRequest("GET", "/echo", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUpgradeRequired || w.Header().Get("Sec-WebSocket-Version") != "13" {
+		t.Errorf("Expected status code %d, got %d", http.StatusUpgradeRequired, w.Code)
+	}
+
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", testWebSocketKey)
+	req.Header.Set("Origin", "http://evil.example")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestWebSocket_EchoAndFragmentation(t *testing.T) {
+	server := newWebSocketTestServer(t)
+	conn, reader, _ := dialWebSocket(t, server, "/ws/echo?token=secret")
+
+	writeClientFrame(t, conn, true, TextMessage, []byte("hello"))
+	if opcode, payload := readServerFrame(t, reader); opcode != TextMessage || string(payload) != "hello" {
+		t.Errorf("Unexpected echo: %d %s", opcode, payload)
+	}
+
+	// A fragmented message with a ping interleaved between the fragments
+	writeClientFrame(t, conn, false, BinaryMessage, []byte("frag"))
+	writeClientFrame(t, conn, true, PingMessage, []byte("p"))
+	writeClientFrame(t, conn, true, continuationFrame, []byte("ment"))
+	if opcode, payload := readServerFrame(t, reader); opcode != PongMessage || string(payload) != "p" {
+		t.Errorf("Expected pong, got %d %s", opcode, payload)
+	}
+	if opcode, payload := readServerFrame(t, reader); opcode != BinaryMessage || string(payload) != "fragment" {
+		t.Errorf("Unexpected reassembled message: %d %s", opcode, payload)
+	}
+}
+
+func TestWebSocket_Close(t *testing.T) {
+	server := newWebSocketTestServer(t)
+	conn, reader, _ := dialWebSocket(t, server, "/ws/echo?token=secret")
+
+	payload := binary.BigEndian.AppendUint16(nil, CloseGoingAway)
+	writeClientFrame(t, conn, true, CloseMessage, payload)
+	opcode, reply := readServerFrame(t, reader)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(reply) != CloseGoingAway {
+		t.Errorf("Expected close echo with code %d, got %d %v", CloseGoingAway, opcode, reply)
+	}
+}
+
+func TestWebSocket_ProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		code  uint16
+	}{
+		{"Unmasked", []byte{0x81, 0x01, 'a'}, CloseProtocolError},
+		{"ReservedBits", []byte{0xC1, 0x80, 0, 0, 0, 0}, CloseProtocolError},
+		{"InvalidUTF8", []byte{0x81, 0x81, 0, 0, 0, 0, 0xFF}, CloseInvalidFramePayloadData},
+		{"OrphanContinuation", []byte{0x80, 0x80, 0, 0, 0, 0}, CloseProtocolError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebSocketTestServer(t)
+			conn, reader, _ := dialWebSocket(t, server, "/ws/echo?token=secret")
+			conn.Write(tt.frame)
+
+			opcode, reply := readServerFrame(t, reader)
+			if opcode != CloseMessage || binary.BigEndian.Uint16(reply) != tt.code {
+				t.Errorf("Expected close with code %d, got %d %v", tt.code, opcode, reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package tsweb
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// mountMethods are the methods routed to handlers attached with Mount.
+var mountMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+// WrapH turns a standard http.Handler into a HandlerFunc.
+func WrapH(handler http.Handler) HandlerFunc {
+	return func(c *Context) {
+		handler.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// WrapF turns a standard http.HandlerFunc into a HandlerFunc.
+func WrapF(handler http.HandlerFunc) HandlerFunc {
+	return WrapH(handler)
+}
+
+// WrapMiddleware turns standard func(http.Handler) http.Handler middleware into
+// middleware for Use. The rest of the chain runs when the wrapped middleware calls
+// its next handler, with the response writer and request it passes on.
+func WrapMiddleware(middleware func(http.Handler) http.Handler) HandlerFunc {
+	return func(c *Context) {
+		writer, req := c.Writer, c.Req
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.Writer, c.Req = w, r
+			c.Next()
+			c.Writer, c.Req = writer, req
+		})
+		middleware(next).ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// SetUsePathValues enables compatibility with net/http ServeMux patterns: matched
+// route parameters are stored with Request.SetPathValue, so wrapped handlers can read
+// them with Request.PathValue, and Context.Param falls back to Request.PathValue for
+// parameters set by a ServeMux the engine is mounted under.
+func (p *Engine) SetUsePathValues(enabled bool) {
+	p.usePathValues = enabled
+}
+
+// setPathValues stores the route parameters of c in its request if UsePathValues is enabled.
+func (p *Engine) setPathValues(c *Context) {
+	if !p.usePathValues {
+		return
+	}
+	for name, value := range c.Params {
+		c.Req.SetPathValue(name, value)
+	}
+}
+
+// Mount routes every request for prefix and the paths below it to handler, which may
+// be another Engine. The group prefix and prefix are stripped from the request path
+// seen by handler, so /debug mounted with /pprof/heap is served as /pprof/heap.
+func (r *RouterGroup) Mount(prefix string, handler http.Handler) {
+	count := len(parsePattern(r.prefix + prefix))
+	mounted := func(c *Context) {
+		req := c.Req.Clone(c.Req.Context())
+		req.URL.Path = trimSegments(req.URL.Path, count)
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = trimSegments(req.URL.RawPath, count)
+		}
+		handler.ServeHTTP(c.Writer, req)
+	}
+	for _, method := range mountMethods {
+		r.addRoute(method, prefix, mounted)
+		r.addRoute(method, path.Join(prefix, "/*mountpath"), mounted)
+	}
+}
+
+// trimSegments removes the first count non-empty segments from p, keeping it rooted.
+func trimSegments(p string, count int) string {
+	for ; count > 0; count-- {
+		p = strings.TrimLeft(p, "/")
+		if index := strings.IndexByte(p, '/'); index >= 0 {
+			p = p[index:]
+		} else {
+			p = ""
+		}
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
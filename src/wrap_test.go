@@ -0,0 +1,179 @@
+package tsweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapH(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/h", WrapH(http.NotFoundHandler()))
+	engine.GET("/f", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "method=%s", r.Method)
+	}))
+
+	req, _ := http.NewRequest("GET", "/h", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/f", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "method=GET" {
+		t.Errorf("Expected body 'method=GET', got '%s'", w.Body.String())
+	}
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	addHeader := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Wrapped", "yes")
+			r.Header.Set("X-Seen", "true")
+			next.ServeHTTP(w, r)
+		})
+	}
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("token") == "" {
+				http.Error(w, "denied", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	engine := NewEngine()
+	engine.Use(WrapMiddleware(addHeader))
+	engine.Use(WrapMiddleware(deny))
+	engine.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "seen=%s", c.Req.Header.Get("X-Seen"))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/?token=1", http.StatusOK, "seen=true"},
+		{"/", http.StatusForbidden, "denied\n"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: expected %d '%s', got %d '%s'", tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Wrapped") != "yes" {
+			t.Errorf("%s: expected X-Wrapped header", tt.path)
+		}
+	}
+}
+
+func TestRouterGroup_Mount(t *testing.T) {
+	sub := NewEngine()
+	sub.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "sub root")
+	})
+	sub.POST("/items/:id", func(c *Context) {
+		c.String(http.StatusOK, "item %s", c.Param("id"))
+	})
+
+	engine := NewEngine()
+	engine.Group("/api").Mount("/v1", sub)
+	engine.Mount("/std", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/api/v1", http.StatusOK, "sub root"},
+		{"GET", "/api/v1/", http.StatusOK, "sub root"},
+		{"POST", "/api/v1/items/7", http.StatusOK, "item 7"},
+		{"GET", "/api/v1/missing", http.StatusNotFound, "404"},
+		{"DELETE", "/std/a/b", http.StatusOK, "DELETE /a/b"},
+		{"GET", "/std", http.StatusOK, "GET /"},
+		{"GET", "/other", http.StatusNotFound, "404"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %d '%s', got %d '%s'", tt.method, tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTrimSegments(t *testing.T) {
+	tests := []struct {
+		path     string
+		count    int
+		expected string
+	}{
+		{"/debug/pprof/heap", 1, "/pprof/heap"},
+		{"/debug", 1, "/"},
+		{"/debug/", 1, "/"},
+		{"//api//v1/x", 2, "/x"},
+		{"/a/b", 0, "/a/b"},
+	}
+	for _, tt := range tests {
+		if trimmed := trimSegments(tt.path, tt.count); trimmed != tt.expected {
+			t.Errorf("trimSegments(%q, %d): expected '%s', got '%s'", tt.path, tt.count, tt.expected, trimmed)
+		}
+	}
+}
+
+func TestEngine_UsePathValues(t *testing.T) {
+	engine := NewEngine()
+	engine.SetUsePathValues(true)
+	engine.GET("/users/:id", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", r.PathValue("id"))
+	}))
+	engine.GET("/teams/:slug/orgs/:org", func(c *Context) {
+		c.String(http.StatusOK, "org %s team %s", c.Param("org"), c.Param("team"))
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/teams/{team}/", engine)
+	mux.Handle("/users/", engine)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users/7", "user 7"},
+		{"/teams/core/orgs/acme", "org acme team core"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Body.String() != tt.body {
+			t.Errorf("%s: expected '%s', got '%s'", tt.path, tt.body, w.Body.String())
+		}
+	}
+
+	engine.SetUsePathValues(false)
+	req, _ := http.NewRequest("GET", "/teams/core/orgs/acme", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Body.String() != "org acme team " {
+		t.Errorf("Expected ServeMux values to be ignored, got '%s'", w.Body.String())
+	}
+	req, _ = http.NewRequest("GET", "/users/7", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "user " {
+		t.Errorf("Expected path values to be unset, got '%s'", w.Body.String())
+	}
+}